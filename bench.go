@@ -0,0 +1,26 @@
+package gexe
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// Bench runs each command, expanded with the session variables, opts.Runs times (after opts.Warmup
+// runs) and returns a *exec.BenchReport with wall, user, and system time statistics for each command.
+// The report can be exported with BenchReport.JSON or BenchReport.Markdown to compare commands.
+func (e *Session) Bench(opts exec.BenchOptions, cmdStrs ...string) *exec.BenchReport {
+	return e.BenchWithContext(context.Background(), opts, cmdStrs...)
+}
+
+// BenchWithContext benchmarks each command, using the specified context (see Session.Bench)
+func (e *Session) BenchWithContext(ctx context.Context, opts exec.BenchOptions, cmdStrs ...string) *exec.BenchReport {
+	hook := opts.ProcHook
+	opts.ProcHook = func(proc *exec.Proc) {
+		e.trackProc(proc)
+		if hook != nil {
+			hook(proc)
+		}
+	}
+	return exec.BenchWithContextVars(ctx, e.vars, opts, cmdStrs...)
+}
@@ -0,0 +1,20 @@
+package gexe
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// Checkpoints returns a *exec.CheckpointBuilder that runs named steps with the session variables and
+// records the completion of each step under stateDir. When run again after a failure, completed steps
+// are skipped unless they are forced, or their commands or the session variables they depend on have changed.
+func (e *Session) Checkpoints(stateDir string) *exec.CheckpointBuilder {
+	return e.CheckpointsWithContext(context.Background(), stateDir)
+}
+
+// CheckpointsWithContext returns a *exec.CheckpointBuilder, that runs with the specified context,
+// for checkpointed steps recorded under stateDir (see Session.Checkpoints).
+func (e *Session) CheckpointsWithContext(ctx context.Context, stateDir string) *exec.CheckpointBuilder {
+	return exec.CheckpointsWithContextVars(ctx, e.vars, stateDir).WithProcHook(e.trackProcHook)
+}
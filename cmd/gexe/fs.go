@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vladimirvivien/gexe"
+)
+
+// fsCmd runs a file system operation. Only "tree" is supported.
+func fsCmd(g *gexe.Session, args []string) int {
+	if len(args) == 0 || args[0] != "tree" {
+		return fail(errors.New("fs: expecting: fs tree [dir]"))
+	}
+
+	flags := flag.NewFlagSet("fs tree", flag.ContinueOnError)
+	depth := flags.Int("depth", 0, "maximum depth to print (0 for no limit)")
+	hidden := flags.Bool("a", false, "include hidden files")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	root := "."
+	if flags.NArg() > 0 {
+		root = g.Eval(flags.Arg(0))
+	}
+	if !g.PathExists(root) {
+		return fail(fmt.Errorf("fs tree: path not found: %s", root))
+	}
+
+	fmt.Fprintln(os.Stdout, root)
+	dirs, files, err := printTree(os.Stdout, root, "", 1, *depth, *hidden)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stdout, "\n%d directories, %d files\n", dirs, files)
+	return 0
+}
+
+// printTree writes the entries of dir, using box-drawing characters, and returns the
+// number of directories and files printed.
+func printTree(out io.Writer, dir, indent string, level, maxDepth int, hidden bool) (dirs, files int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hidden {
+		visible := entries[:0]
+		for _, entry := range entries {
+			if entry.Name()[0] != '.' {
+				visible = append(visible, entry)
+			}
+		}
+		entries = visible
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for i, entry := range entries {
+		branch, next := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(out, "%s%s%s\n", indent, branch, entry.Name())
+
+		if !entry.IsDir() {
+			files++
+			continue
+		}
+		dirs++
+		if maxDepth > 0 && level >= maxDepth {
+			continue
+		}
+		subDirs, subFiles, err := printTree(out, filepath.Join(dir, entry.Name()), indent+next, level+1, maxDepth, hidden)
+		if err != nil {
+			return dirs, files, err
+		}
+		dirs, files = dirs+subDirs, files+subFiles
+	}
+	return dirs, files, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vladimirvivien/gexe"
+)
+
+// headerFlags collects repeated -H "Key: value" flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(val string) error {
+	if !strings.Contains(val, ":") {
+		return fmt.Errorf("invalid header %q, expecting Key: value", val)
+	}
+	*h = append(*h, val)
+	return nil
+}
+
+// httpCmd runs an HTTP operation. Only "get" is supported: it writes the response body
+// to stdout and fails if the response status is not 2xx.
+func httpCmd(g *gexe.Session, args []string) int {
+	if len(args) == 0 || args[0] != "get" {
+		return fail(errors.New("http: expecting: http get <url>"))
+	}
+
+	var headers headerFlags
+	flags := flag.NewFlagSet("http get", flag.ContinueOnError)
+	flags.Var(&headers, "H", "request header (Key: value), can be repeated")
+	timeout := flags.Duration("timeout", 30*time.Second, "request timeout")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		return fail(errors.New("http get: expecting a single url"))
+	}
+
+	req := g.HttpGet(flags.Arg(0)).WithTimeout(*timeout)
+	for _, header := range headers {
+		key, value, _ := strings.Cut(header, ":")
+		req.AddHeader(strings.TrimSpace(key), g.Eval(strings.TrimSpace(value)))
+	}
+
+	res := req.Do()
+	if err := res.Err(); err != nil {
+		return fail(err)
+	}
+	defer res.Body().Close()
+
+	if _, err := io.Copy(os.Stdout, res.Body()); err != nil {
+		return fail(err)
+	}
+	if res.StatusCode() < 200 || res.StatusCode() > 299 {
+		return fail(fmt.Errorf("http get: %s", res.Status()))
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+// Command gexe exposes the gexe library from the shell. It runs gexe scripts
+// (including executable scripts starting with a "#!/usr/bin/env gexe" shebang line),
+// expands variables, and provides helpers commonly needed in CI images:
+//
+//	gexe [script-file] [args...]
+//	gexe script [-e] [-x] <script-file> [args...]
+//	gexe eval <expression>...
+//	gexe run [--parallel] <command>...
+//	gexe http get [-H "Key: value"] [-timeout 30s] <url>
+//	gexe wait-for [-timeout 1m] [-interval 250ms] <tcp:host:port | http(s)://url | path:/file>...
+//	gexe fs tree [-depth n] [dir]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vladimirvivien/gexe"
+)
+
+const usage = `Usage: gexe <command> [arguments]
+
+Commands:
+  <script-file> [args...]    run a gexe script file (same as: gexe script <file>)
+  script [-e] [-x] <file>    run a gexe script file with errexit (-e) or xtrace (-x)
+  eval <expression>...       print expressions with variables expanded
+  run [--parallel] <cmd>...  run commands sequentially, or in parallel with prefixed output
+  http get <url>             print the body of an HTTP GET request
+  wait-for <condition>...    wait for tcp:host:port, http(s)://url, or path:/file conditions
+  fs tree [dir]              print a directory tree
+  help                       print this help
+`
+
+// command runs a subcommand with its arguments and returns the process exit code
+type command func(g *gexe.Session, args []string) int
+
+var commands = map[string]command{
+	"script":   scriptCmd,
+	"eval":     evalCmd,
+	"run":      runCmd,
+	"http":     httpCmd,
+	"wait-for": waitForCmd,
+	"fs":       fsCmd,
+}
+
+func main() {
+	os.Exit(run(gexe.New(), os.Args[1:]))
+}
+
+func run(g *gexe.Session, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	switch name := args[0]; name {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	default:
+		if cmd, ok := commands[name]; ok {
+			return cmd(g, args[1:])
+		}
+		// invoked as a script interpreter (i.e. from a shebang line)
+		if g.PathExists(name) {
+			return scriptCmd(g, args)
+		}
+		return fail(fmt.Errorf("unknown command or script file: %s", name))
+	}
+}
+
+// fail prints err and returns the exit code for a failed command
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "gexe: %s\n", err)
+	return 1
+}
+
+// isTerminal returns true if w is a terminal (character device)
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		spec    string
+		desc    string
+		errored bool
+	}{
+		{spec: "tcp:localhost:5432", desc: "tcp localhost:5432"},
+		{spec: "http://localhost:8080/healthz", desc: "http http://localhost:8080/healthz"},
+		{spec: "https://example.com", desc: "http https://example.com"},
+		{spec: "path:/tmp/ready", desc: "path /tmp/ready"},
+		{spec: "tcp:localhost", errored: true},
+		{spec: "localhost:5432", errored: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			cond, err := parseCondition(test.spec)
+			if test.errored {
+				if err == nil {
+					t.Fatal("expecting error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cond.String() != test.desc {
+				t.Errorf("expecting %q, got %q", test.desc, cond.String())
+			}
+		})
+	}
+}
+
+func TestPrintTree(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/b", ".git"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		depth    int
+		expected string
+		dirs     int
+		files    int
+	}{
+		{
+			name:     "full tree",
+			expected: "├── a\n│   ├── b\n│   │   └── c.txt\n│   └── d.txt\n└── e.txt\n",
+			dirs:     2,
+			files:    3,
+		},
+		{
+			name:     "depth",
+			depth:    1,
+			expected: "├── a\n└── e.txt\n",
+			dirs:     1,
+			files:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			dirs, files, err := printTree(&out, root, "", 1, test.depth, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.expected {
+				t.Errorf("expecting:\n%s\ngot:\n%s", test.expected, out.String())
+			}
+			if dirs != test.dirs || files != test.files {
+				t.Errorf("expecting %d dirs and %d files, got %d and %d", test.dirs, test.files, dirs, files)
+			}
+		})
+	}
+}
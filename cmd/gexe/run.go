@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vladimirvivien/gexe"
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// runCmd runs each argument as a command. Commands run sequentially, stopping at the
+// first failure, or concurrently with --parallel where each output line is prefixed
+// with the command name.
+func runCmd(g *gexe.Session, args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	parallel := flags.Bool("parallel", false, "run commands concurrently with prefixed output")
+	timestamps := flags.Bool("timestamps", false, "prefix output lines with a timestamp (with --parallel)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return fail(errors.New("run: missing command"))
+	}
+
+	var result *exec.CommandResult
+	if *parallel {
+		mux := exec.NewOutputMux(os.Stdout).WithColor(isTerminal(os.Stdout))
+		if *timestamps {
+			mux.WithTimestamps("15:04:05.000")
+		}
+		result = g.Commands(flags.Args()...).WithOutputMux(mux).Concurr().Wait()
+	} else {
+		result = g.Commands(flags.Args()...).
+			WithStdout(os.Stdout).
+			WithStderr(os.Stderr).
+			WithPolicy(exec.ExitOnErrPolicy).
+			Run()
+	}
+
+	errProcs := result.ErrProcs()
+	for _, proc := range errProcs {
+		fmt.Fprintf(os.Stderr, "gexe: %s: %s\n", proc.Command(), proc.Err())
+	}
+	if len(errProcs) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vladimirvivien/gexe"
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// scriptCmd runs a script file. Script arguments are available as variables
+// $1, $2, ..., with $0 set to the script path and $ARGS set to all arguments.
+func scriptCmd(g *gexe.Session, args []string) int {
+	flags := flag.NewFlagSet("script", flag.ContinueOnError)
+	errExit := flags.Bool("e", false, "stop at the first failing line")
+	xtrace := flags.Bool("x", false, "print commands before running them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return fail(errors.New("script: missing script file"))
+	}
+
+	path := flags.Arg(0)
+	scriptArgs := flags.Args()[1:]
+	g.SetVar("0", path)
+	for i, arg := range scriptArgs {
+		g.SetVar(strconv.Itoa(i+1), arg)
+	}
+	g.SetVar("ARGS", strings.Join(scriptArgs, " "))
+
+	sb := g.ScriptFile(path).WithErrExit(*errExit).WithStdout(os.Stdout)
+	if *xtrace {
+		sb.WithXTrace(os.Stderr)
+	}
+	result := sb.Run()
+	return scriptExitCode(result)
+}
+
+// scriptExitCode returns the exit code of a script, which is the exit code of the step that
+// stopped the script or, like a shell, the exit code of its last step.
+func scriptExitCode(result *exec.ScriptResult) int {
+	steps := result.Steps()
+	if err := result.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "gexe: %s\n", err)
+		if len(steps) == 0 {
+			return 1
+		}
+	}
+	if len(steps) == 0 {
+		return 0
+	}
+
+	last := steps[len(steps)-1]
+	if last.Err == nil {
+		return 0
+	}
+	if result.Err() == nil {
+		fmt.Fprintf(os.Stderr, "gexe: line %d: %s\n", last.Line, last.Err)
+	}
+	if last.Proc != nil && last.Proc.ExitCode() > 0 {
+		return last.Proc.ExitCode()
+	}
+	return 1
+}
+
+// evalCmd prints each argument with variables expanded
+func evalCmd(g *gexe.Session, args []string) int {
+	if len(args) == 0 {
+		return fail(errors.New("eval: missing expression"))
+	}
+	for _, arg := range args {
+		fmt.Println(g.Eval(arg))
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/vladimirvivien/gexe"
+	"github.com/vladimirvivien/gexe/wait"
+)
+
+// waitForCmd waits for all conditions to be satisfied
+func waitForCmd(g *gexe.Session, args []string) int {
+	flags := flag.NewFlagSet("wait-for", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", wait.DefaultTimeout, "maximum time to wait for each condition")
+	interval := flags.Duration("interval", wait.DefaultInterval, "polling interval")
+	quiet := flags.Bool("q", false, "do not print conditions as they are satisfied")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return fail(errors.New("wait-for: missing condition"))
+	}
+
+	var conditions []*wait.Condition
+	for _, spec := range flags.Args() {
+		cond, err := parseCondition(g.Eval(spec))
+		if err != nil {
+			return fail(err)
+		}
+		conditions = append(conditions, cond.WithTimeout(*timeout).WithInterval(*interval))
+	}
+
+	if err := g.WaitFor(context.Background(), conditions...); err != nil {
+		return fail(err)
+	}
+	if !*quiet {
+		for _, cond := range conditions {
+			fmt.Fprintf(os.Stderr, "gexe: ready: %s\n", cond)
+		}
+	}
+	return 0
+}
+
+// parseCondition returns the condition for spec, which is one of:
+//
+//	tcp:host:port
+//	http://url or https://url (expecting status 200)
+//	path:/path/to/file
+func parseCondition(spec string) (*wait.Condition, error) {
+	switch {
+	case strings.HasPrefix(spec, "tcp:"):
+		address := strings.TrimPrefix(spec, "tcp:")
+		if !strings.Contains(address, ":") {
+			return nil, fmt.Errorf("wait-for: invalid tcp address %q, expecting tcp:host:port", address)
+		}
+		return wait.TCP(address), nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return wait.HTTP(spec, http.StatusOK), nil
+	case strings.HasPrefix(spec, "path:"):
+		return wait.Path(strings.TrimPrefix(spec, "path:")), nil
+	}
+	return nil, fmt.Errorf("wait-for: unsupported condition %q", spec)
+}
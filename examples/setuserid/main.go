@@ -14,7 +14,7 @@ import (
 // elevated privilege such as using sudo.
 
 func main() {
-	p := gexe.NewProc(`echo "Hello World!`)
+	p := gexe.NewProc(`echo "Hello World!"`)
 	var uid string
 
 	switch runtime.GOOS {
@@ -22,7 +22,7 @@ func main() {
 	case "darwin":
 		uid = gexe.Run(`id -u`)
 	case "linux":
-		uid = gexe.Run(`id -l`)
+		uid = gexe.Run(`id -u`)
 	}
 
 	if uid != "" {
@@ -32,6 +32,14 @@ func main() {
 		}
 	}
 
+	// keep the supplementary groups of the user for group-based file access
+	if runtime.GOOS == "linux" {
+		if err := p.WithUserGroups().Err(); err != nil {
+			fmt.Println("Failed to load user groups: ", err)
+			os.Exit(1)
+		}
+	}
+
 	result := p.Run()
 	if err := result.Err(); err != nil {
 		fmt.Println("Error: ", err)
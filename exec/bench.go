@@ -0,0 +1,283 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// DefaultBenchRuns is the number of measured runs when BenchOptions.Runs is not set
+const DefaultBenchRuns = 10
+
+// BenchOptions configures how commands are benchmarked
+type BenchOptions struct {
+	// Runs is the number of measured runs per command (default: DefaultBenchRuns)
+	Runs int
+	// Warmup is the number of runs, per command, executed before measuring
+	Warmup int
+	// Prepare is a command executed before each run (not measured)
+	Prepare string
+	// Cleanup is a command executed after each run (not measured), even when the
+	// prepare or measured command fails. Its error is reported along with theirs.
+	Cleanup string
+	// IgnoreFailure keeps benchmarking a command when a run exits with an error
+	IgnoreFailure bool
+	// ProcHook is called with each process, including prepare and cleanup commands, before it starts
+	ProcHook func(*Proc)
+}
+
+// BenchRun is the measurement of a single run. Durations are in nanoseconds when exported as JSON.
+type BenchRun struct {
+	Wall     time.Duration `json:"wall"`
+	User     time.Duration `json:"user"`
+	Sys      time.Duration `json:"sys"`
+	MaxRSS   int64         `json:"max_rss"`
+	ExitCode int           `json:"exit_code"`
+}
+
+// BenchStats summarizes a set of durations
+type BenchStats struct {
+	Mean   time.Duration `json:"mean"`
+	StdDev time.Duration `json:"stddev"`
+	Min    time.Duration `json:"min"`
+	Max    time.Duration `json:"max"`
+	Median time.Duration `json:"median"`
+	P90    time.Duration `json:"p90"`
+	P95    time.Duration `json:"p95"`
+	P99    time.Duration `json:"p99"`
+}
+
+// BenchResult is the benchmark result of a command
+type BenchResult struct {
+	Command string     `json:"command"`
+	Runs    []BenchRun `json:"runs"`
+	Wall    BenchStats `json:"wall"`
+	User    BenchStats `json:"user"`
+	Sys     BenchStats `json:"sys"`
+	// MaxRSS is the largest maximum resident set size, in bytes, of all runs (-1 if not supported)
+	MaxRSS int64 `json:"max_rss"`
+	// Err is the error that stopped the benchmark of the command, if any
+	Err error `json:"-"`
+}
+
+// BenchReport stores the benchmark results of one or more commands
+type BenchReport struct {
+	Results []*BenchResult
+}
+
+// BenchWithContextVars benchmarks each command, using the specified context and variables,
+// and returns a *BenchReport that compares them. Commands are benchmarked one after the other.
+func BenchWithContextVars(ctx context.Context, variables *vars.Variables, opts BenchOptions, cmdStrs ...string) *BenchReport {
+	if opts.Runs <= 0 {
+		opts.Runs = DefaultBenchRuns
+	}
+	report := new(BenchReport)
+	for _, cmdStr := range cmdStrs {
+		report.Results = append(report.Results, benchCommand(ctx, variables, opts, cmdStr))
+	}
+	return report
+}
+
+// Bench benchmarks each command and returns a *BenchReport that compares them
+func Bench(opts BenchOptions, cmdStrs ...string) *BenchReport {
+	return BenchWithContextVars(context.Background(), &vars.Variables{}, opts, cmdStrs...)
+}
+
+// benchCommand runs the warmup and measured runs of a command
+func benchCommand(ctx context.Context, variables *vars.Variables, opts BenchOptions, cmdStr string) *BenchResult {
+	result := &BenchResult{Command: variables.ExpandCommand(cmdStr), MaxRSS: -1}
+
+	for i := 0; i < opts.Warmup+opts.Runs; i++ {
+		run, err := benchRun(ctx, variables, opts, cmdStr)
+		if err != nil {
+			result.Err = fmt.Errorf("run %d: %w", i+1, err)
+			break
+		}
+		if i < opts.Warmup {
+			continue
+		}
+		result.Runs = append(result.Runs, run)
+		result.MaxRSS = max(result.MaxRSS, run.MaxRSS)
+	}
+
+	var walls, users, syss []time.Duration
+	for _, run := range result.Runs {
+		walls = append(walls, run.Wall)
+		users = append(users, run.User)
+		syss = append(syss, run.Sys)
+	}
+	result.Wall, result.User, result.Sys = benchStats(walls), benchStats(users), benchStats(syss)
+	return result
+}
+
+// benchRun runs the prepare command, the measured command, and the cleanup command.
+// The cleanup command always runs and its error is joined to the error of the run.
+func benchRun(ctx context.Context, variables *vars.Variables, opts BenchOptions, cmdStr string) (run BenchRun, err error) {
+	if opts.Cleanup != "" {
+		defer func() {
+			if cleanupErr := opts.newProc(ctx, variables, opts.Cleanup).Run().Err(); cleanupErr != nil {
+				err = errors.Join(err, fmt.Errorf("cleanup: %w", cleanupErr))
+			}
+		}()
+	}
+
+	if opts.Prepare != "" {
+		if err := opts.newProc(ctx, variables, opts.Prepare).Run().Err(); err != nil {
+			return BenchRun{}, fmt.Errorf("prepare: %w", err)
+		}
+	}
+
+	// only keep the end of the output, which is reported on failure
+	proc := opts.newProc(ctx, variables, cmdStr).WithCapture(CaptureTail, 4096)
+	start := time.Now()
+	proc.Run()
+	run = BenchRun{
+		Wall:     time.Since(start),
+		User:     proc.UserTime(),
+		Sys:      proc.SysTime(),
+		MaxRSS:   proc.MaxRSS(),
+		ExitCode: proc.ExitCode(),
+	}
+	if err := proc.Err(); err != nil && (!opts.IgnoreFailure || !proc.Exited()) {
+		return run, fmt.Errorf("%w: %s", err, proc.Result())
+	}
+	return run, nil
+}
+
+// newProc creates a process for cmdStr and calls the proc hook, if any
+func (opts BenchOptions) newProc(ctx context.Context, variables *vars.Variables, cmdStr string) *Proc {
+	proc := NewProcWithContextVars(ctx, cmdStr, variables)
+	if opts.ProcHook != nil {
+		opts.ProcHook(proc)
+	}
+	return proc
+}
+
+// benchStats computes the summary statistics of durations
+func benchStats(durations []time.Duration) BenchStats {
+	if len(durations) == 0 {
+		return BenchStats{}
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, d := range sorted {
+		variance += (float64(d) - mean) * (float64(d) - mean)
+	}
+	if len(sorted) > 1 {
+		variance /= float64(len(sorted) - 1)
+	}
+
+	return BenchStats{
+		Mean:   time.Duration(mean),
+		StdDev: time.Duration(math.Sqrt(variance)),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		P95:    percentile(sorted, 95),
+		P99:    percentile(sorted, 99),
+	}
+}
+
+// percentile returns the p-th percentile of sorted durations using linear interpolation
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	weight := rank - float64(lower)
+	return time.Duration(float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight)
+}
+
+// Err returns the errors of the benchmarked commands, if any
+func (r *BenchReport) Err() error {
+	var errs []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", result.Command, result.Err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("bench: %s", strings.Join(errs, "; "))
+}
+
+// Fastest returns the result with the lowest mean wall time
+func (r *BenchReport) Fastest() *BenchResult {
+	var fastest *BenchResult
+	for _, result := range r.Results {
+		if len(result.Runs) == 0 {
+			continue
+		}
+		if fastest == nil || result.Wall.Mean < fastest.Wall.Mean {
+			fastest = result
+		}
+	}
+	return fastest
+}
+
+// Relative returns the mean wall time of result relative to the fastest command (1.0 for the fastest)
+func (r *BenchReport) Relative(result *BenchResult) float64 {
+	fastest := r.Fastest()
+	if fastest == nil || fastest.Wall.Mean == 0 || len(result.Runs) == 0 {
+		return 0
+	}
+	return float64(result.Wall.Mean) / float64(fastest.Wall.Mean)
+}
+
+// JSON returns the report encoded as JSON. Durations are in nanoseconds and sizes in bytes.
+func (r *BenchReport) JSON() ([]byte, error) {
+	type jsonResult struct {
+		*BenchResult
+		Relative float64 `json:"relative"`
+		Error    string  `json:"error,omitempty"`
+	}
+	results := make([]jsonResult, len(r.Results))
+	for i, result := range r.Results {
+		results[i] = jsonResult{BenchResult: result, Relative: r.Relative(result)}
+		if result.Err != nil {
+			results[i].Error = result.Err.Error()
+		}
+	}
+	return json.MarshalIndent(struct {
+		Results []jsonResult `json:"results"`
+	}{Results: results}, "", "  ")
+}
+
+// Markdown returns the report as a markdown table comparing the commands
+func (r *BenchReport) Markdown() string {
+	var md strings.Builder
+	md.WriteString("| Command | Mean [ms] | Min [ms] | Max [ms] | P95 [ms] | User [ms] | System [ms] | Max RSS [MiB] | Relative |\n")
+	md.WriteString("|:---|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, result := range r.Results {
+		rss := "n/a"
+		if result.MaxRSS >= 0 {
+			rss = fmt.Sprintf("%.1f", float64(result.MaxRSS)/(1<<20))
+		}
+		fmt.Fprintf(&md, "| `%s` | %s ± %s | %s | %s | %s | %s | %s | %s | %.2f |\n",
+			strings.ReplaceAll(result.Command, "|", `\|`),
+			millis(result.Wall.Mean), millis(result.Wall.StdDev), millis(result.Wall.Min), millis(result.Wall.Max),
+			millis(result.Wall.P95), millis(result.User.Mean), millis(result.Sys.Mean), rss, r.Relative(result),
+		)
+	}
+	return md.String()
+}
+
+func millis(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenchStats(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		expected  BenchStats
+	}{
+		{name: "empty"},
+		{
+			name:      "single",
+			durations: []time.Duration{5 * ms},
+			expected:  BenchStats{Mean: 5 * ms, Min: 5 * ms, Max: 5 * ms, Median: 5 * ms, P90: 5 * ms, P95: 5 * ms, P99: 5 * ms},
+		},
+		{
+			name:      "unsorted",
+			durations: []time.Duration{4 * ms, 1 * ms, 3 * ms, 2 * ms, 5 * ms},
+			expected: BenchStats{
+				Mean:   3 * ms,
+				StdDev: 1581138 * time.Nanosecond,
+				Min:    1 * ms,
+				Max:    5 * ms,
+				Median: 3 * ms,
+				P90:    4600 * time.Microsecond,
+				P95:    4800 * time.Microsecond,
+				P99:    4960 * time.Microsecond,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats := benchStats(test.durations)
+			if stats != test.expected {
+				t.Errorf("expecting %+v, got %+v", test.expected, stats)
+			}
+		})
+	}
+}
+
+func TestBenchReport(t *testing.T) {
+	report := &BenchReport{Results: []*BenchResult{
+		{Command: "slow | cat", Runs: make([]BenchRun, 2), Wall: BenchStats{Mean: 30 * time.Millisecond}, MaxRSS: 2 << 20},
+		{Command: "fast", Runs: make([]BenchRun, 2), Wall: BenchStats{Mean: 10 * time.Millisecond}, MaxRSS: -1},
+		{Command: "broken", Err: errors.New("exit status 1")},
+	}}
+
+	if report.Fastest().Command != "fast" {
+		t.Errorf("unexpected fastest: %s", report.Fastest().Command)
+	}
+	if rel := report.Relative(report.Results[0]); rel != 3 {
+		t.Errorf("expecting relative 3, got %f", rel)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "broken: exit status 1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	md := report.Markdown()
+	for _, expected := range []string{"| `slow \\| cat` | 30.0 ± 0.0 |", "| 2.0 | 3.00 |", "| n/a | 1.00 |"} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("missing %q in markdown:\n%s", expected, md)
+		}
+	}
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Results []struct {
+			Command  string  `json:"command"`
+			Relative float64 `json:"relative"`
+			Error    string  `json:"error"`
+			Wall     struct {
+				Mean int64 `json:"mean"`
+			} `json:"wall"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Results) != 3 || decoded.Results[0].Wall.Mean != int64(30*time.Millisecond) ||
+		decoded.Results[0].Relative != 3 || decoded.Results[2].Error != "exit status 1" {
+		t.Errorf("unexpected json: %s", data)
+	}
+}
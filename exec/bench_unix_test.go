@@ -0,0 +1,74 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	opts := BenchOptions{
+		Runs:    3,
+		Warmup:  1,
+		Prepare: "touch " + counter,
+		Cleanup: `/bin/sh -c "echo run >> ` + counter + `"`,
+	}
+	report := Bench(opts, "sleep 0.05", "true")
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sleep, noop := report.Results[0], report.Results[1]
+	if len(sleep.Runs) != 3 || len(noop.Runs) != 3 {
+		t.Fatalf("expecting 3 runs, got %d and %d", len(sleep.Runs), len(noop.Runs))
+	}
+	if sleep.Wall.Min < 50_000_000 || sleep.Wall.Mean < sleep.Wall.Min || sleep.Wall.Max < sleep.Wall.P95 {
+		t.Errorf("unexpected wall stats: %+v", sleep.Wall)
+	}
+	if report.Fastest() != noop {
+		t.Errorf("expecting fastest command true, got %s", report.Fastest().Command)
+	}
+	if sleep.MaxRSS <= 0 {
+		t.Errorf("expecting max RSS, got %d", sleep.MaxRSS)
+	}
+
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 8 {
+		t.Errorf("expecting cleanup after 8 runs (including warmup), got %d", runs)
+	}
+}
+
+func TestBench_Failure(t *testing.T) {
+	report := Bench(BenchOptions{Runs: 2}, `/bin/sh -c "echo oops; exit 1"`)
+	result := report.Results[0]
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "oops") || len(result.Runs) != 0 {
+		t.Errorf("expecting failure with output, got %v (%d runs)", result.Err, len(result.Runs))
+	}
+
+	report = Bench(BenchOptions{Runs: 2, IgnoreFailure: true}, `/bin/sh -c "exit 1"`)
+	result = report.Results[0]
+	if result.Err != nil || len(result.Runs) != 2 || result.Runs[0].ExitCode != 1 {
+		t.Errorf("expecting ignored failures, got %v (%d runs)", result.Err, len(result.Runs))
+	}
+
+	counter := filepath.Join(t.TempDir(), "counter")
+	opts := BenchOptions{Runs: 2, Cleanup: `/bin/sh -c "echo run >> ` + counter + `; exit 2"`}
+	result = Bench(opts, `/bin/sh -c "echo oops; exit 1"`).Results[0]
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "oops") || !strings.Contains(result.Err.Error(), "cleanup: exit status 2") {
+		t.Errorf("expecting run and cleanup errors, got %v", result.Err)
+	}
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("expecting cleanup after the failed run, got %d", runs)
+	}
+}
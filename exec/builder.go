@@ -22,6 +22,13 @@ type CommandResult struct {
 	workChan chan *Proc
 	procs    []*Proc
 	errProcs []*Proc
+	steps    []builderStep
+	err      error
+}
+
+// Err returns an error, other than a process error, that prevented commands from executing
+func (cr *CommandResult) Err() error {
+	return cr.err
 }
 
 // Procs return all executed processes
@@ -38,7 +45,19 @@ func (cr *CommandResult) ErrProcs() []*Proc {
 	return cr.errProcs
 }
 
-// Errs returns all errors
+// Results returns the results of the executed commands and functions, in the order they were added
+func (cr *CommandResult) Results() []StepResult {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	results := make([]StepResult, len(cr.steps))
+	for i, step := range cr.steps {
+		results[i] = step.result()
+	}
+	return results
+}
+
+// Errs returns all errors, including errors returned by functions
 func (cr *CommandResult) Errs() (errs []error) {
 	cr.mu.RLock()
 	defer cr.mu.RUnlock()
@@ -46,6 +65,14 @@ func (cr *CommandResult) Errs() (errs []error) {
 	for _, proc := range cr.errProcs {
 		errs = append(errs, fmt.Errorf("%s: %s", proc.Err(), proc.Result()))
 	}
+	for _, step := range cr.steps {
+		if step.fn == nil {
+			continue
+		}
+		if result := step.fn.result(); result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
 	return
 }
 
@@ -105,20 +132,29 @@ func (cr *PipedCommandResult) LastProc() *Proc {
 // CommandBuilder is a batch command builder that
 // can execute commands using different execution policies (i.e. serial, piped, concurrent)
 type CommandBuilder struct {
-	cmdPolicy  CommandPolicy
-	procs      []*Proc
-	vars       *vars.Variables
-	err        error
-	stdout     io.Writer
-	stderr     io.Writer
-	shellStr   string
-	cmdStrings []string
+	cmdPolicy   CommandPolicy
+	procs       []*Proc
+	vars        *vars.Variables
+	err         error
+	stdout      io.Writer
+	stderr      io.Writer
+	shellStr    string
+	cmdStrings  []string
+	ctx         context.Context
+	tasks       map[string]*Task
+	taskOrder   []*Task
+	allowCodes  []int
+	mux         *OutputMux
+	funcs       []*funcStep
+	concurrency int
+	procHook    func(*Proc)
 }
 
 // CommandsWithContextVars creates a *CommandBuilder with the specified context and session variables.
 // The resulting *CommandBuilder is used to execute command strings.
 func CommandsWithContextVars(ctx context.Context, variables *vars.Variables, cmds ...string) *CommandBuilder {
 	cb := new(CommandBuilder)
+	cb.ctx = ctx
 	cb.vars = variables
 	cb.cmdStrings = cmds
 	for _, cmd := range cmds {
@@ -153,11 +189,46 @@ func (cb *CommandBuilder) WithPolicy(policyMask CommandPolicy) *CommandBuilder {
 // Add adds a new command string to the builder
 func (cb *CommandBuilder) Add(cmds ...string) *CommandBuilder {
 	for _, cmd := range cmds {
-		cb.procs = append(cb.procs, NewProc(cb.vars.Eval(cmd)))
+		cb.procs = append(cb.procs, NewProc(cb.vars.ExpandCommand(cmd)).AllowExitCodes(cb.allowCodes...))
+	}
+	return cb
+}
+
+// AddArgs adds a new command, with an explicit argument list, to the builder.
+// The name and args are not parsed nor expanded; use AddProc with Proc.ExpandArgs to expand some of them.
+func (cb *CommandBuilder) AddArgs(name string, args ...string) *CommandBuilder {
+	return cb.AddProc(NewProcArgs(cb.context(), name, args...))
+}
+
+// AddProc adds a process, that has not started, to the builder. The process uses the
+// variables of the builder, for instance to expand the arguments selected with Proc.ExpandArgs:
+//
+//	cb.AddProc(NewProcArgs(ctx, "grep", "-n", userPattern, "$HOME/notes.txt").ExpandArgs(2))
+func (cb *CommandBuilder) AddProc(proc *Proc) *CommandBuilder {
+	cb.procs = append(cb.procs, proc.SetVars(cb.vars).AllowExitCodes(cb.allowCodes...))
+	return cb
+}
+
+// AllowExitCodes sets non-zero exit codes that are accepted as successful for all
+// commands in the builder, including commands added afterward (see Proc.AllowExitCodes).
+// Processes exiting with an accepted code are not reported in CommandResult.ErrProcs.
+func (cb *CommandBuilder) AllowExitCodes(codes ...int) *CommandBuilder {
+	cb.allowCodes = append(cb.allowCodes, codes...)
+	for _, proc := range cb.Procs() {
+		proc.AllowExitCodes(codes...)
 	}
 	return cb
 }
 
+// Procs returns the processes defined in the builder, including task processes
+func (cb *CommandBuilder) Procs() []*Proc {
+	procs := append([]*Proc{}, cb.procs...)
+	for _, task := range cb.taskOrder {
+		procs = append(procs, task.procs...)
+	}
+	return procs
+}
+
 // WithStdout sets the standard output stream for the builder
 func (cb *CommandBuilder) WithStdout(out io.Writer) *CommandBuilder {
 	cb.stdout = out
@@ -170,6 +241,23 @@ func (cb *CommandBuilder) WithStderr(err io.Writer) *CommandBuilder {
 	return cb
 }
 
+// WithOutputMux sends the combined output of all commands to mux, where each line is prefixed
+// with a label derived from the command name (see OutputMux). This takes precedence over
+// WithStdout and WithStderr, and is safe to use with concurrent execution:
+//
+//	mux := exec.NewOutputMux(os.Stdout).WithColor(true)
+//	exec.Commands("make web", "make api").WithOutputMux(mux).Concurr().Wait()
+func (cb *CommandBuilder) WithOutputMux(mux *OutputMux) *CommandBuilder {
+	cb.mux = mux
+	return cb
+}
+
+// WithProcHook sets a function that is called with each process before it starts
+func (cb *CommandBuilder) WithProcHook(hook func(*Proc)) *CommandBuilder {
+	cb.procHook = hook
+	return cb
+}
+
 // WithWorkDir sets the working directory for all defined commands
 func (cb *CommandBuilder) WithWorkDir(dir string) *CommandBuilder {
 	for _, proc := range cb.procs {
@@ -184,16 +272,25 @@ func (cb *CommandBuilder) WithShell(shell string) *CommandBuilder {
 	return cb
 }
 
-// Run executes all commands successively and waits for all of the result. The result of each individual
+// Run executes all commands, and functions, successively and waits for all of the result. The result of each individual
 // command can be accessed from CommandResult.Procs[] after the execution completes. If policy == ExitOnErrPolicy, the
 // execution will stop on the first error encountered, otherwise it will continue. Processes with errors can be accessed
-// from CommandResult.ErrProcs.
+// from CommandResult.ErrProcs, and the results of all steps from CommandResult.Results.
 func (cb *CommandBuilder) Run() *CommandResult {
 	var result CommandResult
-	for _, p := range cb.procs {
-		result.procs = append(result.procs, p)
-		if err := cb.runCommand(p); err != nil {
-			result.errProcs = append(result.errProcs, p)
+	for _, step := range cb.steps() {
+		if cb.context().Err() != nil {
+			break
+		}
+		result.steps = append(result.steps, step)
+		if step.proc != nil {
+			result.procs = append(result.procs, step.proc)
+			cb.setupProc(step.proc)
+		}
+		if err := cb.runStep(cb.context(), step); err != nil {
+			if step.proc != nil {
+				result.errProcs = append(result.errProcs, step.proc)
+			}
 			if hasPolicy(cb.cmdPolicy, ExitOnErrPolicy) {
 				break
 			}
@@ -208,62 +305,92 @@ func (cb *CommandBuilder) Run() *CommandResult {
 // to complete. Use CommandResult.Wait to wait for the processes to complete. Then, the result of each command can be accessed
 // from CommandResult.Procs[] or CommandResult.ErrProcs to access failed processses. If policy == ExitOnErrPolicy, the execution will halt
 // on the first error encountered, otherwise it will continue.
+//
+// Functions added with AddFunc run to completion, in order, when started sequentially. When started concurrently, all steps
+// run at the same time up to the limit set with WithConcurrency, and all of them are stopped when the builder's context is done
+// or, with ExitOnErrPolicy, when a step fails.
 func (cb *CommandBuilder) Start() *CommandResult {
 	result := &CommandResult{workChan: make(chan *Proc, len(cb.procs))}
 	go func(builder *CommandBuilder, cr *CommandResult) {
 		defer close(cr.workChan)
 
-		// start with concurrently and wait for all procs to launch
+		// run concurrently and wait for all steps to complete
 		if hasPolicy(builder.cmdPolicy, ConcurrentExecPolicy) {
+			// the first failure stops the other steps with ExitOnErrPolicy
+			ctx, cancel := context.WithCancel(builder.context())
+			defer cancel()
+			var limit chan struct{}
+			if builder.concurrency > 0 {
+				limit = make(chan struct{}, builder.concurrency)
+			}
+
 			var gate sync.WaitGroup
-			for _, proc := range builder.procs {
-				cr.mu.Lock()
-				cr.procs = append(cr.procs, proc)
-				cr.mu.Unlock()
+			for _, step := range builder.steps() {
+				if limit != nil {
+					select {
+					case limit <- struct{}{}:
+					case <-ctx.Done():
+					}
+				}
+				if ctx.Err() != nil {
+					break
+				}
 
-				// setup standard output/err
-				proc.cmd.Stdout = cb.stdout
-				if cb.stdout == nil {
-					proc.cmd.Stdout = proc.result
+				cr.mu.Lock()
+				cr.steps = append(cr.steps, step)
+				if step.proc != nil {
+					cr.procs = append(cr.procs, step.proc)
 				}
+				cr.mu.Unlock()
 
-				proc.cmd.Stderr = cb.stderr
-				if cb.stderr == nil {
-					proc.cmd.Stderr = proc.result
+				// set up procs in order so that output labels are deterministic
+				if step.proc != nil {
+					builder.setupProc(step.proc)
 				}
 
 				gate.Add(1)
-				go func(conProc *Proc, conResult *CommandResult) {
-					conResult.mu.Lock()
-					defer conResult.mu.Unlock()
+				go func(conStep builderStep) {
 					defer gate.Done()
-					if err := conProc.Start().Err(); err != nil {
-						cr.errProcs = append(cr.errProcs, conProc)
+					if limit != nil {
+						defer func() { <-limit }()
+					}
+					err := builder.runStep(ctx, conStep)
+					if err == nil {
 						return
 					}
-					conResult.workChan <- conProc
-				}(proc, cr)
+					if conStep.proc != nil {
+						cr.mu.Lock()
+						cr.errProcs = append(cr.errProcs, conStep.proc)
+						cr.mu.Unlock()
+					}
+					if hasPolicy(builder.cmdPolicy, ExitOnErrPolicy) {
+						cancel()
+					}
+				}(step)
 			}
 			gate.Wait()
 			return
 		}
 
 		// start sequentially
-		for _, proc := range builder.procs {
+		for _, step := range builder.steps() {
 			cr.mu.Lock()
-			cr.procs = append(cr.procs, proc)
+			cr.steps = append(cr.steps, step)
+			if step.proc != nil {
+				cr.procs = append(cr.procs, step.proc)
+			}
 			cr.mu.Unlock()
 
-			// setup standard output/err
-			proc.cmd.Stdout = cb.stdout
-			if cb.stdout == nil {
-				proc.cmd.Stdout = proc.result
+			// functions run to completion before the next step starts
+			if step.fn != nil {
+				if err := step.fn.run(builder.context()); err != nil && hasPolicy(builder.cmdPolicy, ExitOnErrPolicy) {
+					break
+				}
+				continue
 			}
 
-			proc.cmd.Stderr = cb.stderr
-			if cb.stderr == nil {
-				proc.cmd.Stderr = proc.result
-			}
+			proc := step.proc
+			builder.setupProc(proc)
 
 			// start sequentially
 			if err := proc.Start().Err(); err != nil {
@@ -291,17 +418,7 @@ func (cb *CommandBuilder) Concurr() *CommandResult {
 }
 
 func (cb *CommandBuilder) runCommand(proc *Proc) error {
-	// setup standard out and standard err
-
-	proc.cmd.Stdout = cb.stdout
-	if cb.stdout == nil {
-		proc.cmd.Stdout = proc.result
-	}
-
-	proc.cmd.Stderr = cb.stderr
-	if cb.stderr == nil {
-		proc.cmd.Stderr = proc.result
-	}
+	cb.setupProc(proc)
 
 	if err := proc.Start().Err(); err != nil {
 		return err
@@ -313,15 +430,49 @@ func (cb *CommandBuilder) runCommand(proc *Proc) error {
 	return nil
 }
 
+// Wait waits for all started commands, and functions, to complete
 func (cr *CommandResult) Wait() *CommandResult {
 	for proc := range cr.workChan {
 		if err := proc.Wait().Err(); err != nil {
+			cr.mu.Lock()
 			cr.errProcs = append(cr.errProcs, proc)
+			cr.mu.Unlock()
 		}
 	}
 	return cr
 }
 
+// setupProc calls the proc hook, if any, and sets up the standard output and error streams of proc
+func (cb *CommandBuilder) setupProc(proc *Proc) {
+	if cb.procHook != nil {
+		cb.procHook(proc)
+	}
+
+	if cb.mux != nil {
+		out := cb.mux.Writer(procLabel(proc))
+		proc.cmd.Stdout, proc.cmd.Stderr = out, out
+		proc.outClosers = append(proc.outClosers, out)
+		return
+	}
+
+	proc.cmd.Stdout = cb.stdout
+	if cb.stdout == nil {
+		proc.cmd.Stdout = proc.result
+	}
+
+	proc.cmd.Stderr = cb.stderr
+	if cb.stderr == nil {
+		proc.cmd.Stderr = proc.result
+	}
+}
+
+func (cb *CommandBuilder) context() context.Context {
+	if cb.ctx == nil {
+		return context.Background()
+	}
+	return cb.ctx
+}
+
 func hasPolicy(mask, pol CommandPolicy) bool {
 	return (mask & pol) != 0
 }
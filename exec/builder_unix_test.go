@@ -3,7 +3,15 @@
 package exec
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
 )
 
 func TestCommandBuilder(t *testing.T) {
@@ -174,14 +182,6 @@ func TestCommandBuilder_Start(t *testing.T) {
 			expectedErrs: 2,
 			policy:       ConcurrentExecPolicy,
 		},
-		{
-			name:         "concurrent on errors",
-			commands:     []string{"echo 'hello world'", "daftpunk", `date "+%Y-%m-%d"`, "ls -al"},
-			results:      []string{"hello world", Run(`daftpunk`), Run(`date "+%Y-%m-%d"`), Run(`ls -al`)},
-			expectedCmds: 4,
-			expectedErrs: 1,
-			policy:       ExitOnErrPolicy | ConcurrentExecPolicy, // ExitOnErr is ignored when concurrent
-		},
 	}
 
 	for _, test := range tests {
@@ -207,3 +207,266 @@ func TestCommandBuilder_Start(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandBuilder_AllowExitCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		commands     []string
+		allowed      []int
+		expectedErrs int
+	}{
+		{
+			name:         "no codes allowed",
+			commands:     []string{`/bin/sh -c "echo hello | grep world"`, `/bin/sh -c "exit 2"`},
+			expectedErrs: 2,
+		},
+		{
+			name:         "no match allowed",
+			commands:     []string{`/bin/sh -c "echo hello | grep world"`, `/bin/sh -c "exit 2"`},
+			allowed:      []int{1},
+			expectedErrs: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := Commands(test.commands[0]).AllowExitCodes(test.allowed...).Add(test.commands[1:]...)
+			result := cb.Run()
+			if len(result.ErrProcs()) != test.expectedErrs {
+				t.Errorf("expecting %d errors, got %d: %v", test.expectedErrs, len(result.ErrProcs()), result.Errs())
+			}
+			if code := result.Procs()[0].ExitCode(); code != 1 {
+				t.Errorf("expecting exit code 1, got %d", code)
+			}
+		})
+	}
+}
+
+func TestCommandBuilder_AddArgs(t *testing.T) {
+	variables := vars.New().SetVar("NAME", "world")
+	cb := CommandsWithVars(variables, "echo $NAME").AddArgs("echo", "$NAME", `"quoted" arg`).
+		AddProc(NewProcArgs(context.Background(), "echo", "$NAME", "${NAME}").ExpandArgs(1))
+	result := cb.Run()
+	if len(result.ErrProcs()) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errs())
+	}
+
+	expected := []string{"world", `$NAME "quoted" arg`, "$NAME world"}
+	for i, proc := range result.Procs() {
+		if proc.Result() != expected[i] {
+			t.Errorf("expecting %q, got %q", expected[i], proc.Result())
+		}
+	}
+}
+
+func TestCommandBuilder_WithOutputMux(t *testing.T) {
+	var out bytes.Buffer
+	mux := NewOutputMux(&out)
+	result := Commands(
+		`/bin/sh -c "for i in 1 2 3; do echo one-\$i; sleep 0.01; done"`,
+		`/bin/sh -c "for i in 1 2 3; do echo two-\$i; sleep 0.01; done; printf partial"`,
+		`echo three`,
+	).WithOutputMux(mux).Concurr().Wait()
+	if len(result.ErrProcs()) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errs())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("expecting 8 lines, got %d:\n%s", len(lines), out.String())
+	}
+	for _, expected := range []string{"sh   | one-3", "sh-2 | two-3", "sh-2 | partial", "echo | three"} {
+		if !strings.Contains(out.String(), expected+"\n") {
+			t.Errorf("missing line %q in output:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestCommandBuilder_AddFunc(t *testing.T) {
+	failed := errors.New("upload failed")
+	tests := []struct {
+		name     string
+		builder  func(calls *[]string) *CommandBuilder
+		policy   CommandPolicy
+		expected []string
+		errs     []bool
+	}{
+		{
+			name: "mixed steps in order",
+			builder: func(calls *[]string) *CommandBuilder {
+				return Commands("echo one").
+					AddFunc("render", func(ctx context.Context) error { *calls = append(*calls, "render"); return nil }).
+					Add("echo two").
+					AddFunc("upload", func(ctx context.Context) error { *calls = append(*calls, "upload"); return nil })
+			},
+			expected: []string{"echo one", "render", "echo two", "upload"},
+			errs:     []bool{false, false, false, false},
+		},
+		{
+			name: "continue after func error",
+			builder: func(calls *[]string) *CommandBuilder {
+				return Commands().
+					AddFunc("upload", func(ctx context.Context) error { return failed }).
+					Add("echo done")
+			},
+			expected: []string{"upload", "echo done"},
+			errs:     []bool{true, false},
+		},
+		{
+			name: "stop on func error",
+			builder: func(calls *[]string) *CommandBuilder {
+				return Commands().
+					AddFunc("upload", func(ctx context.Context) error { return failed }).
+					Add("echo done")
+			},
+			policy:   ExitOnErrPolicy,
+			expected: []string{"upload"},
+			errs:     []bool{true},
+		},
+		{
+			name: "func panic",
+			builder: func(calls *[]string) *CommandBuilder {
+				return Commands().AddFunc("render", func(ctx context.Context) error { panic("bad template") })
+			},
+			expected: []string{"render"},
+			errs:     []bool{true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls []string
+			result := test.builder(&calls).WithPolicy(test.policy).Run()
+			results := result.Results()
+			if len(results) != len(test.expected) {
+				t.Fatalf("expecting %d results, got %d", len(test.expected), len(results))
+			}
+			for i, res := range results {
+				if res.Name != test.expected[i] {
+					t.Errorf("expecting step %q, got %q", test.expected[i], res.Name)
+				}
+				if (res.Err != nil) != test.errs[i] {
+					t.Errorf("step %s: unexpected error: %v", res.Name, res.Err)
+				}
+				if (res.Proc == nil) == strings.HasPrefix(res.Name, "echo") {
+					t.Errorf("step %s: unexpected proc: %v", res.Name, res.Proc)
+				}
+				if res.Err == nil && res.Duration <= 0 {
+					t.Errorf("step %s: expecting a duration", res.Name)
+				}
+			}
+			for _, call := range calls {
+				if !strings.Contains(strings.Join(test.expected, ","), call) {
+					t.Errorf("unexpected call to %s", call)
+				}
+			}
+
+			errCount := 0
+			for _, hasErr := range test.errs {
+				if hasErr {
+					errCount++
+				}
+			}
+			if len(result.Errs()) != errCount {
+				t.Errorf("expecting %d errors, got %v", errCount, result.Errs())
+			}
+		})
+	}
+}
+
+func TestCommandBuilder_WithConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	step := func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+
+	cb := Commands("sleep 0.05", "sleep 0.05")
+	for _, name := range []string{"a", "b", "c", "d"} {
+		cb.AddFunc(name, step)
+	}
+	result := cb.WithConcurrency(2).Concurr().Wait()
+	if len(result.Errs()) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errs())
+	}
+	if len(result.Results()) != 6 {
+		t.Fatalf("expecting 6 results, got %d", len(result.Results()))
+	}
+	if len(result.Procs()) != 2 {
+		t.Errorf("expecting 2 procs, got %d", len(result.Procs()))
+	}
+	if maxRunning.Load() > 2 {
+		t.Errorf("expecting at most 2 concurrent functions, got %d", maxRunning.Load())
+	}
+}
+
+func TestCommandBuilder_ConcurrentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	result := CommandsWithContext(ctx).
+		Add("sleep 10").
+		AddFunc("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		AddFunc("cancel", func(context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+			return nil
+		}).
+		Concurr().Wait()
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("steps were not canceled, took %s", elapsed)
+	}
+	results := result.Results()
+	if len(results) != 3 {
+		t.Fatalf("expecting 3 results, got %d", len(results))
+	}
+	if results[0].Err == nil || results[0].Proc == nil {
+		t.Errorf("expecting killed process, got: %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("expecting context.Canceled, got: %v", results[1].Err)
+	}
+	if results[2].Err != nil {
+		t.Errorf("unexpected error: %v", results[2].Err)
+	}
+}
+
+func TestCommandBuilder_ConcurrentExitOnErr(t *testing.T) {
+	start := time.Now()
+	result := Commands("sleep 10", `/bin/sh -c "sleep 0.1; exit 1"`).
+		AddFunc("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		WithPolicy(ExitOnErrPolicy | ConcurrentExecPolicy).Start().Wait()
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("steps were not stopped by the failure, took %s", elapsed)
+	}
+	results := result.Results()
+	if len(results) != 3 {
+		t.Fatalf("expecting 3 results, got %d", len(results))
+	}
+	if results[0].Err == nil || results[0].Proc.ExitCode() == 0 {
+		t.Errorf("expecting killed process, got: %+v", results[0])
+	}
+	if results[1].Proc.ExitCode() != 1 {
+		t.Errorf("expecting failed process, got exit code %d", results[1].Proc.ExitCode())
+	}
+	if !errors.Is(results[2].Err, context.Canceled) {
+		t.Errorf("expecting context.Canceled, got: %v", results[2].Err)
+	}
+}
@@ -0,0 +1,337 @@
+package exec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/vladimirvivien/gexe/fs"
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// checkpointNameRegex matches characters that are replaced in checkpoint state file names
+var checkpointNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// CheckpointStep is a named step, made of commands or a function, of a CheckpointBuilder
+type CheckpointStep struct {
+	name     string
+	cmdStrs  []string
+	fn       func(context.Context) error
+	varNames []string
+	procs    []*Proc
+	status   TaskStatus
+	err      error
+	duration time.Duration
+}
+
+// Vars declares variables that invalidate the step checkpoint when their values change.
+// Variables referenced by the step commands are always included.
+func (s *CheckpointStep) Vars(names ...string) *CheckpointStep {
+	s.varNames = append(s.varNames, names...)
+	return s
+}
+
+// Name returns the step name
+func (s *CheckpointStep) Name() string {
+	return s.name
+}
+
+// Procs returns the processes executed by the step
+func (s *CheckpointStep) Procs() []*Proc {
+	return s.procs
+}
+
+// Status returns the step status: TaskSucceeded, TaskFailed, TaskUpToDate when the step was
+// skipped because of its checkpoint, or TaskPending when it did not run because a previous step failed.
+func (s *CheckpointStep) Status() TaskStatus {
+	return s.status
+}
+
+// Err returns the error that caused the step to fail
+func (s *CheckpointStep) Err() error {
+	return s.err
+}
+
+// Duration returns how long the step took to execute
+func (s *CheckpointStep) Duration() time.Duration {
+	return s.duration
+}
+
+// CheckpointResult stores the result of a checkpointed execution
+type CheckpointResult struct {
+	steps []*CheckpointStep
+	err   error
+}
+
+// Err returns the error of the failed step, or an error that prevented execution
+func (cr *CheckpointResult) Err() error {
+	return cr.err
+}
+
+// Steps returns all steps, in order
+func (cr *CheckpointResult) Steps() []*CheckpointStep {
+	return cr.steps
+}
+
+// SkippedSteps returns the steps that were skipped because they had completed in a previous run
+func (cr *CheckpointResult) SkippedSteps() (steps []*CheckpointStep) {
+	for _, step := range cr.steps {
+		if step.status == TaskUpToDate {
+			steps = append(steps, step)
+		}
+	}
+	return
+}
+
+// CheckpointBuilder runs named steps, one after the other, and records the completion of each step
+// in a state file under a state directory. Execution stops at the first failing step. When run again,
+// steps that have completed are skipped unless they are forced (see CheckpointBuilder.Force) or their
+// commands (including the aliases they use), or the values of their variables, have changed since they completed:
+//
+//	cp := exec.Checkpoints(".provision")
+//	cp.Step("network", "terraform apply -auto-approve -target=module.network")
+//	cp.Step("db", "./create-db.sh $DB_NAME")
+//	cp.StepFunc("seed", seed).Vars("DB_NAME")
+//	if err := cp.Run().Err(); err != nil {
+//		// fix the problem and rerun, completed steps are skipped
+//	}
+//
+// A step that runs again does not invalidate the checkpoints of the steps that follow it.
+type CheckpointBuilder struct {
+	ctx      context.Context
+	vars     *vars.Variables
+	stateDir string
+	steps    []*CheckpointStep
+	forced   map[string]bool
+	forceAll bool
+	stdout   io.Writer
+	procHook func(*Proc)
+}
+
+// CheckpointsWithContextVars creates a *CheckpointBuilder that stores its state in stateDir,
+// using the specified context and session variables.
+func CheckpointsWithContextVars(ctx context.Context, variables *vars.Variables, stateDir string) *CheckpointBuilder {
+	return &CheckpointBuilder{
+		ctx:      ctx,
+		vars:     variables,
+		stateDir: variables.Eval(stateDir),
+		forced:   make(map[string]bool),
+	}
+}
+
+// Checkpoints creates a *CheckpointBuilder that stores its state in stateDir
+func Checkpoints(stateDir string) *CheckpointBuilder {
+	return CheckpointsWithContextVars(context.Background(), vars.New(), stateDir)
+}
+
+// Step declares a step, made of commands cmdStrs executed sequentially. If the step
+// already exists, the commands are appended to it.
+func (cb *CheckpointBuilder) Step(name string, cmdStrs ...string) *CheckpointStep {
+	step := cb.step(name)
+	step.cmdStrs = append(step.cmdStrs, cmdStrs...)
+	return step
+}
+
+// StepFunc declares a step that calls fn with the builder's context. Use
+// CheckpointStep.Vars to declare the variables that fn depends on. A step cannot
+// have both commands and a function, CheckpointBuilder.Run returns an error.
+func (cb *CheckpointBuilder) StepFunc(name string, fn func(ctx context.Context) error) *CheckpointStep {
+	step := cb.step(name)
+	step.fn = fn
+	return step
+}
+
+// Force runs the named steps even if they have completed, or all steps when no name is provided
+func (cb *CheckpointBuilder) Force(names ...string) *CheckpointBuilder {
+	if len(names) == 0 {
+		cb.forceAll = true
+	}
+	for _, name := range names {
+		cb.forced[name] = true
+	}
+	return cb
+}
+
+// WithStdout streams the combined output of each command to out.
+// By default, the output is captured and available from CheckpointStep.Procs.
+func (cb *CheckpointBuilder) WithStdout(out io.Writer) *CheckpointBuilder {
+	cb.stdout = out
+	return cb
+}
+
+// WithProcHook sets a function that is called with each process before it starts
+func (cb *CheckpointBuilder) WithProcHook(hook func(*Proc)) *CheckpointBuilder {
+	cb.procHook = hook
+	return cb
+}
+
+// StateDir returns the directory where step completions are recorded
+func (cb *CheckpointBuilder) StateDir() string {
+	return cb.stateDir
+}
+
+// Reset removes the recorded completion of all steps
+func (cb *CheckpointBuilder) Reset() error {
+	return fs.Path(cb.stateDir).Remove().Err()
+}
+
+// Run runs the steps in order, skipping steps that have completed in a previous run,
+// and records the completion of each step. It stops at the first failing step.
+func (cb *CheckpointBuilder) Run() *CheckpointResult {
+	result := &CheckpointResult{steps: cb.steps}
+	for _, step := range cb.steps {
+		step.status, step.err, step.procs = TaskPending, nil, nil
+	}
+
+	if cb.stateDir == "" {
+		result.err = fmt.Errorf("checkpoint: state directory not set")
+		return result
+	}
+	for _, step := range cb.steps {
+		if step.fn != nil && len(step.cmdStrs) > 0 {
+			result.err = fmt.Errorf("checkpoint: step %s: declared with both commands and a function", step.name)
+			return result
+		}
+	}
+	if err := fs.Path(cb.stateDir).MkDir(0755).Err(); err != nil {
+		result.err = fmt.Errorf("checkpoint: %w", err)
+		return result
+	}
+
+	for _, step := range cb.steps {
+		if err := cb.context().Err(); err != nil {
+			result.err = err
+			return result
+		}
+
+		stateHash := cb.stateHash(step)
+		if !cb.forceAll && !cb.forced[step.name] && cb.completed(step, stateHash) {
+			step.status = TaskUpToDate
+			continue
+		}
+
+		step.status = TaskRunning
+		start := time.Now()
+		err := cb.runStep(step)
+		if err == nil {
+			err = cb.record(step, stateHash)
+		}
+		step.duration = time.Since(start)
+		if err != nil {
+			step.status = TaskFailed
+			step.err = fmt.Errorf("step %s: %w", step.name, err)
+			result.err = step.err
+			return result
+		}
+		step.status = TaskSucceeded
+	}
+	return result
+}
+
+// runStep runs the commands, or function, of the step
+func (cb *CheckpointBuilder) runStep(step *CheckpointStep) error {
+	if step.fn != nil {
+		return step.fn(cb.context())
+	}
+	for _, cmdStr := range step.cmdStrs {
+		proc := NewProcWithContextVars(cb.context(), cmdStr, cb.vars)
+		step.procs = append(step.procs, proc)
+		if cb.stdout != nil && proc.Err() == nil {
+			proc.cmd.Stdout, proc.cmd.Stderr = cb.stdout, cb.stdout
+		}
+		if cb.procHook != nil {
+			cb.procHook(proc)
+		}
+		if err := proc.Run().Err(); err != nil {
+			return fmt.Errorf("%s: %w: %s", cmdStr, err, proc.Result())
+		}
+	}
+	return nil
+}
+
+// completed returns true if the step completion was recorded with the same commands and variable values
+func (cb *CheckpointBuilder) completed(step *CheckpointStep, stateHash string) bool {
+	path := cb.statePath(step)
+	if !fs.Path(path).Exists() {
+		return false
+	}
+	reader := fs.Read(path)
+	if reader.Err() != nil {
+		return false
+	}
+	state := vars.New().Vars(reader.Lines()...)
+	return state.Val("CHECKPOINT_HASH") == stateHash
+}
+
+// record writes the step state file
+func (cb *CheckpointBuilder) record(step *CheckpointStep, stateHash string) error {
+	return fs.Write(cb.statePath(step)).Lines([]string{
+		fmt.Sprintf("CHECKPOINT_HASH=%s", stateHash),
+		fmt.Sprintf("CHECKPOINT_TIME=%s", time.Now().UTC().Format(time.RFC3339)),
+	}).Err()
+}
+
+// stateHash returns a hash of the step commands, with aliases expanded, and of the values of the
+// variables the step depends on: its declared variables and the variables referenced in its commands.
+func (cb *CheckpointBuilder) stateHash(step *CheckpointStep) string {
+	names := make(map[string]bool)
+	for _, name := range step.varNames {
+		names[name] = true
+	}
+	for _, cmdStr := range step.cmdStrs {
+		cb.vars.ExpandVar(cmdStr, func(name string) string {
+			names[name] = true
+			return ""
+		})
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	hash := sha256.New()
+	for _, cmdStr := range step.cmdStrs {
+		fmt.Fprintf(hash, "cmd %q\n", cb.vars.ExpandCommand(cmdStr))
+	}
+	for _, name := range sorted {
+		fmt.Fprintf(hash, "%s=%s\n", name, cb.vars.Val(name))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// statePath returns the path of the step state file. Names with replaced characters are
+// suffixed with a hash of the name, so that names such as "a/b" and "a b" do not collide.
+func (cb *CheckpointBuilder) statePath(step *CheckpointStep) string {
+	name := checkpointNameRegex.ReplaceAllString(step.name, "_")
+	if name != step.name {
+		sum := sha256.Sum256([]byte(step.name))
+		name = fmt.Sprintf("%s-%s", name, hex.EncodeToString(sum[:4]))
+	}
+	return filepath.Join(cb.stateDir, name+".done")
+}
+
+func (cb *CheckpointBuilder) step(name string) *CheckpointStep {
+	for _, step := range cb.steps {
+		if step.name == name {
+			return step
+		}
+	}
+	step := &CheckpointStep{name: name}
+	cb.steps = append(cb.steps, step)
+	return step
+}
+
+func (cb *CheckpointBuilder) context() context.Context {
+	if cb.ctx == nil {
+		return context.Background()
+	}
+	return cb.ctx
+}
@@ -0,0 +1,153 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+func TestCheckpointBuilder(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "state")
+	variables := vars.New().SetVar("TARGET", "staging")
+	calls := make(map[string]int)
+	failSeed := true
+
+	build := func() *CheckpointBuilder {
+		cp := CheckpointsWithContextVars(context.Background(), variables, stateDir)
+		cp.StepFunc("network", func(context.Context) error { calls["network"]++; return nil })
+		cp.StepFunc("db", func(context.Context) error { calls["db"]++; return nil }).Vars("TARGET")
+		cp.StepFunc("seed", func(context.Context) error {
+			calls["seed"]++
+			if failSeed {
+				return errors.New("seed failed")
+			}
+			return nil
+		})
+		cp.StepFunc("verify", func(context.Context) error { calls["verify"]++; return nil })
+		return cp
+	}
+
+	statuses := func(result *CheckpointResult) (statuses []TaskStatus) {
+		for _, step := range result.Steps() {
+			statuses = append(statuses, step.Status())
+		}
+		return
+	}
+	check := func(name string, result *CheckpointResult, expected []TaskStatus, expectedCalls map[string]int) {
+		t.Helper()
+		actual := statuses(result)
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("%s: expecting statuses %v, got %v", name, expected, actual)
+				break
+			}
+		}
+		for step, count := range expectedCalls {
+			if calls[step] != count {
+				t.Errorf("%s: expecting %d calls to %s, got %d", name, count, step, calls[step])
+			}
+		}
+	}
+
+	// first run fails at seed
+	result := build().Run()
+	if result.Err() == nil {
+		t.Fatal("expecting seed failure")
+	}
+	check("first run", result,
+		[]TaskStatus{TaskSucceeded, TaskSucceeded, TaskFailed, TaskPending},
+		map[string]int{"network": 1, "db": 1, "seed": 1, "verify": 0})
+
+	// rerun resumes at seed
+	failSeed = false
+	result = build().Run()
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	check("resume", result,
+		[]TaskStatus{TaskUpToDate, TaskUpToDate, TaskSucceeded, TaskSucceeded},
+		map[string]int{"network": 1, "db": 1, "seed": 2, "verify": 1})
+	if len(result.SkippedSteps()) != 2 {
+		t.Errorf("expecting 2 skipped steps, got %d", len(result.SkippedSteps()))
+	}
+
+	// changed variable invalidates db
+	variables.SetVar("TARGET", "production")
+	result = build().Run()
+	check("changed var", result,
+		[]TaskStatus{TaskUpToDate, TaskSucceeded, TaskUpToDate, TaskUpToDate},
+		map[string]int{"network": 1, "db": 2, "seed": 2, "verify": 1})
+
+	// forced step
+	result = build().Force("verify").Run()
+	check("forced", result,
+		[]TaskStatus{TaskUpToDate, TaskUpToDate, TaskUpToDate, TaskSucceeded},
+		map[string]int{"network": 1, "db": 2, "seed": 2, "verify": 2})
+
+	// reset
+	cp := build()
+	if err := cp.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stateDir); !os.IsNotExist(err) {
+		t.Fatalf("expecting state dir to be removed: %v", err)
+	}
+	result = cp.Run()
+	check("reset", result,
+		[]TaskStatus{TaskSucceeded, TaskSucceeded, TaskSucceeded, TaskSucceeded},
+		map[string]int{"network": 2, "db": 3, "seed": 3, "verify": 3})
+}
+
+func TestCheckpointBuilder_StepWithCommandsAndFunc(t *testing.T) {
+	called := false
+	cp := Checkpoints(filepath.Join(t.TempDir(), "state"))
+	cp.Step("setup", "echo setup")
+	cp.StepFunc("setup", func(context.Context) error { called = true; return nil })
+
+	result := cp.Run()
+	if result.Err() == nil || !strings.Contains(result.Err().Error(), "step setup") {
+		t.Errorf("expecting error for step with commands and a function, got: %v", result.Err())
+	}
+	if called || result.Steps()[0].Status() != TaskPending {
+		t.Errorf("expecting step not to run, got %s", result.Steps()[0].Status())
+	}
+}
+
+func TestCheckpointBuilder_StateNames(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "state")
+	calls := make(map[string]int)
+	build := func() *CheckpointBuilder {
+		cp := Checkpoints(stateDir)
+		for _, name := range []string{"a/b", "a b", "a_b"} {
+			cp.StepFunc(name, func(context.Context) error { calls[name]++; return nil })
+		}
+		return cp
+	}
+
+	for range 2 {
+		if err := build().Run().Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, count := range calls {
+		if count != 1 {
+			t.Errorf("expecting step %q to run once, ran %d times", name, count)
+		}
+	}
+
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expecting 3 state files, got %d", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "a_b.done")); err != nil {
+		t.Errorf("expecting unchanged name for step a_b: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !windows
+
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+func TestCheckpointBuilder_Commands(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, "state")
+	logFile := filepath.Join(dir, "log")
+	variables := vars.New().SetVar("LOG", logFile).SetVar("NAME", "one").SetVar("FAIL", "false")
+
+	firstCmd := `sh -c "echo first-$NAME >> $LOG"`
+	build := func() *CheckpointBuilder {
+		cp := CheckpointsWithContextVars(context.Background(), variables, stateDir)
+		cp.Step("first", firstCmd)
+		cp.Step("second", `sh -c "echo second >> $LOG"`, "$FAIL")
+		return cp
+	}
+
+	result := build().Run()
+	if result.Err() == nil || !strings.Contains(result.Err().Error(), "step second") {
+		t.Fatalf("expecting failure of step second, got: %v", result.Err())
+	}
+	if len(result.Steps()[1].Procs()) != 2 {
+		t.Errorf("expecting 2 procs for step second, got %d", len(result.Steps()[1].Procs()))
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "first.done")); err != nil {
+		t.Errorf("expecting state file for step first: %v", err)
+	}
+
+	variables.SetVar("FAIL", "true")
+	if err := build().Run().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a changed variable referenced by a command invalidates its step
+	variables.SetVar("NAME", "two")
+	if err := build().Run().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an edited command invalidates its step
+	firstCmd = `sh -c "echo edited-$NAME >> $LOG"`
+	result = build().Run()
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Steps()[0].Status() != TaskSucceeded || result.Steps()[1].Status() != TaskUpToDate {
+		t.Errorf("expecting only step first to run, got %s and %s", result.Steps()[0].Status(), result.Steps()[1].Status())
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "first-one\nsecond\nsecond\nfirst-two\nedited-two\n"
+	if string(data) != expected {
+		t.Errorf("expecting log:\n%s\ngot:\n%s", expected, string(data))
+	}
+}
+
+func TestCheckpointBuilder_Aliases(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "log")
+	variables := vars.New().SetVar("LOG", logFile).Alias("logit", `sh -c "echo one >> $LOG"`)
+	run := func() *CheckpointResult {
+		cp := CheckpointsWithContextVars(context.Background(), variables, filepath.Join(dir, "state"))
+		cp.Step("log", "logit")
+		return cp.Run()
+	}
+
+	if err := run().Err(); err != nil {
+		t.Fatal(err)
+	}
+	if status := run().Steps()[0].Status(); status != TaskUpToDate {
+		t.Errorf("expecting step up to date, got %s", status)
+	}
+
+	// a changed alias invalidates the steps that use it
+	variables.Alias("logit", `sh -c "echo two >> $LOG"`)
+	if status := run().Steps()[0].Status(); status != TaskSucceeded {
+		t.Errorf("expecting step to run again, got %s", status)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("unexpected log: %q", data)
+	}
+}
@@ -1,110 +1,168 @@
 package exec
 
 import (
-	"bufio"
-	"io"
+	"fmt"
+	"runtime"
 	"strings"
 	"unicode"
 )
 
-// parse parses space-separated string into words including quoted words:
+// escapeOutsideQuotes is false on Windows, where backslashes are path separators
+const escapeOutsideQuotes = runtime.GOOS != "windows"
+
+// ParseError is returned when a command string cannot be tokenized.
+// Column is the 1-based position (in runes) where the error was detected.
+type ParseError struct {
+	Input  string
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at column %d: %s", e.Column, e.Msg)
+}
+
+// parse splits a command string into words following POSIX shell quoting rules:
 //
-//	aaa "bbb" "ccc ddd" '"eee ff"'
+//	aaa "bbb" "ccc ddd" '"eee ff"' --opt="a b"'c' "" a\ b
 //
 //	NB:
-//	- aaa"abcd": returns whole string.
-//	- "aaa"bbb:  returns two words "aaa" and "bbb"
+//	- adjacent quoted and unquoted segments form one word: --opt="a b"'c' returns --opt=a bc
+//	- an empty quoted string ("" or '') is an empty word
+//	- outside quotes, a backslash escapes the next character (\<newline> is removed),
+//	  except on Windows where it is a literal character (i.e. C:\Users\app.exe)
+//	- inside double quotes, a backslash only escapes $, `, ", \ and newline; on Windows,
+//	  a backslash before a closing quote that ends the word is literal (i.e. "C:\dir\")
+//	- inside single quotes, every character is literal
+//
+// An unterminated quote or a trailing backslash returns a *ParseError.
 func parse(val string) ([]string, error) {
-	rdr := bufio.NewReader(strings.NewReader(val))
-	var startQuote rune
-	var word strings.Builder
+	return tokenize(val, escapeOutsideQuotes)
+}
+
+// tokenize splits val into words (see parse). When escapes is false,
+// a backslash outside quotes, or before a double quote that ends the word, is a literal character.
+func tokenize(val string, escapes bool) ([]string, error) {
+	runes := []rune(val)
 	words := make([]string, 0)
+	var word strings.Builder
 	inWord := false
-	inQuote := false
-	squashed := false
-
-	for {
-		token, _, err := rdr.ReadRune()
-		if err != nil {
-			if err == io.EOF {
-				remainder := word.String()
-				if len(remainder) > 0 {
-					words = append(words, remainder)
-				}
-				return words, nil
-			}
-			return nil, err
-		}
 
+	for i := 0; i < len(runes); i++ {
+		token := runes[i]
 		switch {
-		case isChar(token):
-			if !inWord {
-				inWord = true
+		case unicode.IsSpace(token):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
 			}
-			word.WriteRune(token)
 
-		case isQuote(token):
-			if !inWord {
-				inWord, inQuote = true, true
-				startQuote = token
-				continue
+		case token == '\\' && escapes:
+			if i+1 >= len(runes) {
+				return nil, &ParseError{Input: val, Column: i + 1, Msg: "trailing backslash"}
 			}
-
-			// handles case when unquoted runs into quoted: abc"defg"
-			// start the quote here
-			if inWord && !inQuote {
-				inQuote, squashed = true, true
-				startQuote = token
-				word.WriteRune(token)
+			i++
+			if runes[i] == '\n' {
 				continue
 			}
+			inWord = true
+			word.WriteRune(runes[i])
 
-			// handle embedded quote (i.e "'aa'")
-			if inWord && inQuote && token != startQuote {
-				word.WriteRune(token)
-				continue
+		case token == '\'':
+			start := i
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
 			}
-
-			// capture closing quote when in abc"defg"
-			if squashed {
-				word.WriteRune(token)
+			if i >= len(runes) {
+				return nil, &ParseError{Input: val, Column: start + 1, Msg: "unterminated single quote"}
 			}
 
-			inWord = false
-			inQuote = false
-			squashed = false
-			//store
-			words = append(words, word.String())
-			word.Reset()
-
-		case unicode.IsSpace(token):
-			if !inWord {
-				inWord = false
-				continue
+		case token == '"':
+			start := i
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && isDoubleQuoteEscape(runes[i+1]) {
+					if !escapes && runes[i+1] == '"' && endsWord(runes, i+2) {
+						word.WriteRune(runes[i])
+						continue
+					}
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
 			}
-
-			// capture quoted space
-			if inWord && inQuote {
-				word.WriteRune(token)
-				continue
+			if i >= len(runes) {
+				return nil, &ParseError{Input: val, Column: start + 1, Msg: "unterminated double quote"}
 			}
 
-			// end of word
-			inWord = false
-			words = append(words, word.String())
-			word.Reset()
+		default:
+			inWord = true
+			word.WriteRune(token)
 		}
 	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
 }
 
-func isQuote(r rune) bool {
+// isDoubleQuoteEscape returns true for the runes a backslash escapes within double quotes
+func isDoubleQuoteEscape(r rune) bool {
 	switch r {
-	case '"', '\'':
+	case '$', '`', '"', '\\', '\n':
 		return true
 	}
 	return false
 }
 
-func isChar(r rune) bool {
-	return !isQuote(r) && !unicode.IsSpace(r)
+// endsWord returns true if position i is the end of runes or a space
+func endsWord(runes []rune, i int) bool {
+	return i >= len(runes) || unicode.IsSpace(runes[i])
+}
+
+// Quote returns a command string for args that, when tokenized with Parse,
+// returns the original args. Args that need quoting are single-quoted.
+// A single quote within an arg is escaped with a backslash or, on Windows, with double quotes.
+// A $ within an arg is placed in double quotes as \$, so the command string also
+// round-trips through variable expansion (i.e. Session.Run), which ignores single quotes.
+func Quote(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteArg(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if strings.IndexFunc(arg, needsQuote) < 0 {
+		return arg
+	}
+	escaped := `'\''`
+	if !escapeOutsideQuotes {
+		escaped = `'"'"'`
+	}
+	quoted := strings.NewReplacer("'", escaped, "$", `'"\$"'`).Replace(arg)
+	quoted = "'" + quoted + "'"
+	// drop the empty quotes left when an escaped rune starts or ends the arg
+	quoted = strings.TrimPrefix(quoted, "''")
+	return strings.TrimSuffix(quoted, "''")
+}
+
+// needsQuote returns true for runes that are not safe to leave unquoted
+func needsQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	case strings.ContainsRune("-_./:=,+@%", r):
+		return false
+	}
+	return true
 }
@@ -1,6 +1,10 @@
 package exec
 
-import "testing"
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
 
 func TestEchoSplitWords(t *testing.T) {
 	tests := []struct {
@@ -31,12 +35,12 @@ func TestEchoSplitWords(t *testing.T) {
 		{
 			name:  "front quote runin",
 			str:   `aaa"bbb ccc" ddd`,
-			words: []string{"aaa\"bbb ccc\"", "ddd"},
+			words: []string{"aaabbb ccc", "ddd"},
 		},
 		{
 			name:  "back quote runin",
 			str:   `aaa "bbb ccc"ddd`,
-			words: []string{"aaa", "bbb ccc", "ddd"},
+			words: []string{"aaa", "bbb cccddd"},
 		},
 		{
 			name:  "embedded single quotes",
@@ -51,23 +55,58 @@ func TestEchoSplitWords(t *testing.T) {
 		{
 			name:  "embedded double quotes runins",
 			str:   `aaa'"bbb ccc"' ddd`,
-			words: []string{`aaa'"bbb ccc"'`, "ddd"},
+			words: []string{`aaa"bbb ccc"`, "ddd"},
 		},
 		{
 			name:  "embedded single quotes runins",
 			str:   `aaa"bbb 'ccc'" ddd`,
-			words: []string{`aaa"bbb 'ccc'"`, "ddd"},
+			words: []string{`aaabbb 'ccc'`, "ddd"},
 		},
 		{
 			name:  "actual exec command",
 			str:   `/bin/bash -c 'gexe "Hello World"'`,
 			words: []string{`/bin/bash`, `-c`, `gexe "Hello World"`},
 		},
+		{
+			name:  "adjacent quoted segments",
+			str:   `cmd --opt="a b"'c'd`,
+			words: []string{"cmd", "--opt=a bcd"},
+		},
+		{
+			name:  "empty args",
+			str:   `aaa "" '' bbb`,
+			words: []string{"aaa", "", "", "bbb"},
+		},
+		{
+			name:  "escaped space",
+			str:   `ls /tmp/my\ dir`,
+			words: []string{"ls", "/tmp/my dir"},
+		},
+		{
+			name:  "escaped quotes",
+			str:   `echo \"aaa\" "b\"b" 'c\d'`,
+			words: []string{"echo", `"aaa"`, `b"b`, `c\d`},
+		},
+		{
+			name:  "backslash in double quotes",
+			str:   `echo "a\b\\c\$d"`,
+			words: []string{"echo", `a\b\c$d`},
+		},
+		{
+			name:  "line continuation",
+			str:   "aaa \\\nbbb",
+			words: []string{"aaa", "bbb"},
+		},
+		{
+			name:  "blank",
+			str:   "  \t ",
+			words: []string{},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			words, err := parse(test.str)
+			words, err := tokenize(test.str, true)
 			if err != nil {
 				t.Error(err)
 			}
@@ -82,3 +121,103 @@ func TestEchoSplitWords(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenizeLiteralBackslash(t *testing.T) {
+	tests := []struct {
+		name  string
+		str   string
+		words []string
+	}{
+		{name: "path", str: `C:\Users\x\app.exe --dir D:\data\`, words: []string{`C:\Users\x\app.exe`, "--dir", `D:\data\`}},
+		{name: "quoted path", str: `"C:\Program Files\app.exe" 'C:\a b'`, words: []string{`C:\Program Files\app.exe`, `C:\a b`}},
+		{name: "escaped quote in double quotes", str: `echo "a\"b"`, words: []string{"echo", `a"b`}},
+		{name: "backslash before space", str: `echo a\ b`, words: []string{"echo", `a\`, "b"}},
+		{name: "trailing backslash in double quotes", str: `dir "C:\my dir\" "D:\x\"`, words: []string{"dir", `C:\my dir\`, `D:\x\`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := tokenize(test.str, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != len(test.words) {
+				t.Fatalf("unexpected length: want %#v, got %#v", test.words, words)
+			}
+			for i := range words {
+				if words[i] != test.words[i] {
+					t.Errorf("word mismatched:\ngot %#v\nwant %#v", words, test.words)
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		str    string
+		column int
+	}{
+		{name: "unterminated double quote", str: `echo "hello world`, column: 6},
+		{name: "unterminated single quote", str: `echo a 'b c`, column: 8},
+		{name: "quote closed by other quote", str: `echo "a'`, column: 6},
+		{name: "trailing backslash", str: `echo abc\`, column: 9},
+		{name: "multibyte column", str: `échö "x`, column: 6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := tokenize(test.str, true)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expecting *ParseError, got %v", err)
+			}
+			if perr.Column != test.column {
+				t.Errorf("expecting column %d, got %d: %s", test.column, perr.Column, perr)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		quoted    string
+		winQuoted string
+	}{
+		{name: "plain", args: []string{"ls", "-la", "/tmp"}, quoted: `ls -la /tmp`},
+		{name: "spaces", args: []string{"cat", "/tmp/my file"}, quoted: `cat '/tmp/my file'`},
+		{name: "empty", args: []string{"echo", ""}, quoted: `echo ''`},
+		{name: "single quote", args: []string{"echo", "it's"}, quoted: `echo 'it'\''s'`, winQuoted: `echo 'it'"'"'s'`},
+		{name: "special chars", args: []string{"echo", `"a" \b $c`, "*"}, quoted: `echo '"a" \b '"\$"'c' '*'`},
+		{name: "dollar", args: []string{"echo", "$HOME", "a$", "$"}, quoted: `echo "\$"'HOME' 'a'"\$" "\$"`},
+		{name: "newline", args: []string{"printf", "a\nb"}, quoted: "printf 'a\nb'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected := test.quoted
+			if runtime.GOOS == "windows" && test.winQuoted != "" {
+				expected = test.winQuoted
+			}
+			quoted := Quote(test.args...)
+			if quoted != expected {
+				t.Errorf("expecting %s, got %s", expected, quoted)
+			}
+			words, err := Parse(quoted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != len(test.args) {
+				t.Fatalf("round trip mismatch: want %#v, got %#v", test.args, words)
+			}
+			for i := range words {
+				if words[i] != test.args[i] {
+					t.Errorf("round trip mismatch: want %#v, got %#v", test.args, words)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+//go:build windows
+
+package exec
+
+import (
+	"testing"
+)
+
+func TestParseWindows(t *testing.T) {
+	tests := []struct {
+		name  string
+		str   string
+		words []string
+	}{
+		{name: "executable path", str: `C:\Users\x\app.exe /v`, words: []string{`C:\Users\x\app.exe`, "/v"}},
+		{name: "quoted path", str: `"C:\Program Files\app.exe" --out D:\data\`, words: []string{`C:\Program Files\app.exe`, "--out", `D:\data\`}},
+		{name: "UNC path", str: `dir \\server\share`, words: []string{"dir", `\\server\share`}},
+		{name: "quoted trailing backslash", str: `xcopy "C:\my dir\" D:\`, words: []string{"xcopy", `C:\my dir\`, `D:\`}},
+		{name: "quote", str: Quote("echo", `C:\it's here`), words: []string{"echo", `C:\it's here`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := Parse(test.str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != len(test.words) {
+				t.Fatalf("unexpected length: want %#v, got %#v", test.words, words)
+			}
+			for i := range words {
+				if words[i] != test.words[i] {
+					t.Errorf("word mismatched:\ngot %#v\nwant %#v", words, test.words)
+				}
+			}
+		})
+	}
+
+	proc := NewProc(`C:\Windows\System32\cmd.exe /c echo hello`)
+	if args := proc.Command().Args; len(args) != 4 || args[0] != `C:\Windows\System32\cmd.exe` {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
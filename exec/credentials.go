@@ -0,0 +1,172 @@
+package exec
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// groupFile is the group database used to load the supplementary groups of a user
+const groupFile = "/etc/group"
+
+// capabilities maps Linux capability names to their numbers (see capabilities(7))
+var capabilities = map[string]uintptr{
+	"CAP_CHOWN":              0,
+	"CAP_DAC_OVERRIDE":       1,
+	"CAP_DAC_READ_SEARCH":    2,
+	"CAP_FOWNER":             3,
+	"CAP_FSETID":             4,
+	"CAP_KILL":               5,
+	"CAP_SETGID":             6,
+	"CAP_SETUID":             7,
+	"CAP_SETPCAP":            8,
+	"CAP_LINUX_IMMUTABLE":    9,
+	"CAP_NET_BIND_SERVICE":   10,
+	"CAP_NET_BROADCAST":      11,
+	"CAP_NET_ADMIN":          12,
+	"CAP_NET_RAW":            13,
+	"CAP_IPC_LOCK":           14,
+	"CAP_IPC_OWNER":          15,
+	"CAP_SYS_MODULE":         16,
+	"CAP_SYS_RAWIO":          17,
+	"CAP_SYS_CHROOT":         18,
+	"CAP_SYS_PTRACE":         19,
+	"CAP_SYS_PACCT":          20,
+	"CAP_SYS_ADMIN":          21,
+	"CAP_SYS_BOOT":           22,
+	"CAP_SYS_NICE":           23,
+	"CAP_SYS_RESOURCE":       24,
+	"CAP_SYS_TIME":           25,
+	"CAP_SYS_TTY_CONFIG":     26,
+	"CAP_MKNOD":              27,
+	"CAP_LEASE":              28,
+	"CAP_AUDIT_WRITE":        29,
+	"CAP_AUDIT_CONTROL":      30,
+	"CAP_SETFCAP":            31,
+	"CAP_MAC_OVERRIDE":       32,
+	"CAP_MAC_ADMIN":          33,
+	"CAP_SYSLOG":             34,
+	"CAP_WAKE_ALARM":         35,
+	"CAP_BLOCK_SUSPEND":      36,
+	"CAP_AUDIT_READ":         37,
+	"CAP_PERFMON":            38,
+	"CAP_BPF":                39,
+	"CAP_CHECKPOINT_RESTORE": 40,
+}
+
+// SetGroups looks up the groups, by numerical ids or by names, used as
+// the supplementary groups of the process when launched.
+func (p *Proc) SetGroups(groups ...string) *Proc {
+	if p.err != nil {
+		return p
+	}
+	for _, grp := range groups {
+		gid, err := lookupGroupID(grp)
+		if err != nil {
+			p.err = err
+			return p
+		}
+		p.groups = append(p.groups, gid)
+	}
+	return p
+}
+
+// WithUserGroups loads, from /etc/group, the groups of the user set with Proc.SetUserid and
+// uses them as the supplementary groups of the process, similar to a login. The primary group
+// of the user is used unless a group is set with Proc.SetGroupid. It must follow SetUserid.
+func (p *Proc) WithUserGroups() *Proc {
+	if p.err != nil {
+		return p
+	}
+	if p.userid == nil {
+		p.err = fmt.Errorf("user groups: SetUserid must be called first")
+		return p
+	}
+	usr, err := user.LookupId(strconv.Itoa(*p.userid))
+	if err != nil {
+		p.err = fmt.Errorf("user groups: %w", err)
+		return p
+	}
+	gids, err := loadUserGroups(groupFile, usr.Username)
+	if err != nil {
+		p.err = fmt.Errorf("user groups: %w", err)
+		return p
+	}
+	if gid, err := strconv.Atoi(usr.Gid); err == nil {
+		gids = append([]int{gid}, gids...)
+		// use the primary group of the user, unless set with SetGroupid
+		if p.groupid == nil {
+			p.groupid = &gid
+		}
+	}
+	p.groups = append(p.groups, gids...)
+	return p
+}
+
+// NoSetGroups starts the process without setting its supplementary groups, so that it
+// keeps the groups of the running program instead of the groups set with Proc.SetGroups.
+func (p *Proc) NoSetGroups() *Proc {
+	p.noSetGroups = true
+	return p
+}
+
+// SetAmbientCaps keeps the specified Linux capabilities (i.e. CAP_NET_BIND_SERVICE),
+// as ambient capabilities, in the process when launched, including after a change of
+// user with Proc.SetUserid. The running program must have the capabilities in its
+// permitted set. On platforms other than Linux, Proc.Err returns an error.
+func (p *Proc) SetAmbientCaps(caps ...string) *Proc {
+	if p.err != nil {
+		return p
+	}
+	if runtime.GOOS != "linux" {
+		p.err = fmt.Errorf("ambient capabilities: not supported on %s", runtime.GOOS)
+		return p
+	}
+	for _, name := range caps {
+		capNum, ok := capabilities[strings.ToUpper(name)]
+		if !ok {
+			p.err = fmt.Errorf("ambient capabilities: unknown capability %s", name)
+			return p
+		}
+		p.ambientCaps = append(p.ambientCaps, capNum)
+	}
+	return p
+}
+
+// loadUserGroups returns the ids of the groups, in the group file at path, that list username as a member
+func loadUserGroups(path, username string) ([]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var gids []int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// name:password:gid:member,member...
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 {
+			continue
+		}
+		gid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		for _, member := range strings.Split(fields[3], ",") {
+			if strings.TrimSpace(member) == username {
+				gids = append(gids, gid)
+				break
+			}
+		}
+	}
+	return gids, scanner.Err()
+}
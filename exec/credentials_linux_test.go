@@ -0,0 +1,86 @@
+//go:build linux
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcSupplementaryGroups(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("requires root")
+	}
+
+	tests := []struct {
+		name     string
+		proc     func() *Proc
+		expected string
+	}{
+		{
+			name:     "no supplementary groups",
+			proc:     func() *Proc { return NewProc("id -G").SetUserid("65534").SetGroupid("65534") },
+			expected: "65534",
+		},
+		{
+			name:     "supplementary groups",
+			proc:     func() *Proc { return NewProc("id -G").SetUserid("65534").SetGroupid("65534").SetGroups("1", "0") },
+			expected: "65534 0 1",
+		},
+		{
+			name:     "group without user",
+			proc:     func() *Proc { return NewProc("id -G").SetGroups("1") },
+			expected: "0 1",
+		},
+		{
+			name:     "user groups",
+			proc:     func() *Proc { return NewProc("id -G").SetUserid("65534").WithUserGroups() },
+			expected: "65534",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.proc().Run()
+			if err := p.Err(); err != nil {
+				t.Fatalf("%s: %s", err, p.Result())
+			}
+			if p.Result() != test.expected {
+				t.Errorf("expecting groups %q, got %q", test.expected, p.Result())
+			}
+		})
+	}
+}
+
+func TestProcAmbientCaps(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("requires root")
+	}
+
+	p := NewProc("grep CapAmb /proc/self/status").SetUserid("65534").SetAmbientCaps("cap_net_bind_service").Run()
+	if err := p.Err(); err != nil {
+		t.Fatalf("%s: %s", err, p.Result())
+	}
+	if !strings.HasSuffix(p.Result(), "0000000000000400") {
+		t.Errorf("expecting CAP_NET_BIND_SERVICE in ambient set, got %q", p.Result())
+	}
+}
+
+func TestProcPrivilegeError(t *testing.T) {
+	uid := 65534
+	p := &Proc{userid: &uid, groups: []int{1}, ambientCaps: []uintptr{10}}
+	err := p.startError(syscall.EPERM)
+	if !errors.Is(err, syscall.EPERM) {
+		t.Fatalf("expecting EPERM, got: %v", err)
+	}
+	for _, expected := range []string{"user or group credentials", "supplementary groups", "ambient capabilities"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expecting %q in error: %v", expected, err)
+		}
+	}
+	if err := p.startError(syscall.ENOENT); err != syscall.ENOENT {
+		t.Errorf("expecting unchanged error, got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadUserGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "group")
+	data := `# comment
+root:x:0:
+wheel:x:10:alice,bob
+docker:x:999:bob
+staff:x:50:carol, alice
+broken:x:abc:alice
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user     string
+		expected []int
+	}{
+		{user: "alice", expected: []int{10, 50}},
+		{user: "bob", expected: []int{10, 999}},
+		{user: "dave", expected: nil},
+	}
+	for _, test := range tests {
+		gids, err := loadUserGroups(path, test.user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gids, test.expected) {
+			t.Errorf("%s: expecting groups %v, got %v", test.user, test.expected, gids)
+		}
+	}
+
+	if _, err := loadUserGroups(filepath.Join(t.TempDir(), "missing"), "alice"); err == nil {
+		t.Error("expecting error for missing group file")
+	}
+}
+
+func TestProcCredentialErrors(t *testing.T) {
+	if err := NewProc("echo hello").WithUserGroups().Err(); err == nil || !strings.Contains(err.Error(), "SetUserid") {
+		t.Errorf("expecting SetUserid error, got: %v", err)
+	}
+
+	err := NewProc("echo hello").SetAmbientCaps("CAP_NOT_A_CAP").Err()
+	expected := "unknown capability"
+	if runtime.GOOS != "linux" {
+		expected = "not supported"
+	}
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expecting error containing %q, got: %v", expected, err)
+	}
+}
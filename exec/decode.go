@@ -0,0 +1,229 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// DecodeError is returned when the output of a process cannot be decoded or
+// when the process fails. It reports the command, its exit error, its standard
+// error output, and the position (in stdout) where decoding failed.
+type DecodeError struct {
+	Command string
+	Format  string
+	ExitErr error
+	Stderr  string
+	Err     error
+	Offset  int64
+	Line    int
+	Column  int
+}
+
+func (e *DecodeError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%s output of %q", e.Format, e.Command)
+	if e.Err != nil {
+		if e.Line > 0 {
+			fmt.Fprintf(&msg, ": line %d, column %d", e.Line, e.Column)
+		}
+		fmt.Fprintf(&msg, ": %s", e.Err)
+	}
+	if e.ExitErr != nil {
+		fmt.Fprintf(&msg, ": command failed: %s", e.ExitErr)
+		if e.Stderr != "" {
+			fmt.Fprintf(&msg, ": %s", e.Stderr)
+		}
+	}
+	return msg.String()
+}
+
+// Unwrap returns the decoding error and the process exit error
+func (e *DecodeError) Unwrap() []error {
+	var errs []error
+	for _, err := range []error{e.Err, e.ExitErr} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// JSON starts the process, waits for it to complete, and decodes its standard output,
+// as JSON, into v. Standard error is not decoded, it is available from Proc.Result.
+// If the process fails or its output cannot be decoded, JSON returns a *DecodeError.
+func (p *Proc) JSON(v any) error {
+	data, err := p.runStdout("json")
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	decodeErr := dec.Decode(v)
+	if decodeErr == io.EOF {
+		decodeErr = errors.New("no output to decode")
+	}
+	if decodeErr != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(decodeErr, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(decodeErr, &typeErr):
+			offset = typeErr.Offset
+		}
+		decErr := p.decodeError("json", decodeErr)
+		decErr.Offset = offset
+		decErr.Line, decErr.Column = position(data, offset)
+		return decErr
+	}
+	return p.exitError("json")
+}
+
+// CSV starts the process, waits for it to complete, and parses its standard output
+// as comma-separated values. It returns a *DecodeError if the process fails or if the output
+// cannot be parsed.
+func (p *Proc) CSV() ([][]string, error) {
+	data, err := p.runStdout("csv")
+	if err != nil {
+		return nil, err
+	}
+
+	records, csvErr := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if csvErr != nil {
+		decErr := p.decodeError("csv", csvErr)
+		var parseErr *csv.ParseError
+		if errors.As(csvErr, &parseErr) {
+			decErr.Err = parseErr.Err
+			decErr.Line, decErr.Column = parseErr.Line, parseErr.Column
+		}
+		return nil, decErr
+	}
+	return records, p.exitError("csv")
+}
+
+// Lines starts the process, waits for it to complete, and returns the lines of its standard output.
+// It returns a *DecodeError if the process fails.
+func (p *Proc) Lines() ([]string, error) {
+	data, err := p.runStdout("lines")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(data), p.exitError("lines")
+}
+
+// Fields starts the process, waits for it to complete, and splits each line of its standard
+// output into fields separated by sep. If sep is empty, lines are split around runs of white space
+// (see strings.Fields). Blank lines are skipped. It returns a *DecodeError if the process fails.
+func (p *Proc) Fields(sep string) ([][]string, error) {
+	data, err := p.runStdout("fields")
+	if err != nil {
+		return nil, err
+	}
+
+	var fields [][]string
+	for _, line := range splitLines(data) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if sep == "" {
+			fields = append(fields, strings.Fields(line))
+			continue
+		}
+		fields = append(fields, strings.Split(line, sep))
+	}
+	return fields, p.exitError("fields")
+}
+
+// RunJSON runs the command string and decodes its standard output, as JSON, into a value of type T:
+//
+//	pods, err := exec.RunJSON[PodList]("kubectl get pods -o json")
+func RunJSON[T any](cmdStr string) (T, error) {
+	return RunJSONWithContextVars[T](context.Background(), cmdStr, &vars.Variables{})
+}
+
+// RunJSONWithContextVars runs the command string, with the specified context and variables,
+// and decodes its standard output, as JSON, into a value of type T.
+func RunJSONWithContextVars[T any](ctx context.Context, cmdStr string, variables *vars.Variables) (T, error) {
+	var v T
+	err := NewProcWithContextVars(ctx, cmdStr, variables).JSON(&v)
+	return v, err
+}
+
+// runStdout runs the process with its standard output captured separately
+// from its standard error and returns the standard output. If the process
+// cannot be started, it returns a *DecodeError wrapping the start error.
+func (p *Proc) runStdout(format string) ([]byte, error) {
+	if p.err != nil {
+		return nil, &DecodeError{Command: p.commandString(), Format: format, Err: p.err}
+	}
+	if p.hasStarted() {
+		return nil, &DecodeError{Command: p.commandString(), Format: format, Err: errors.New("process already started")}
+	}
+
+	var stdout bytes.Buffer
+	if p.cmd.Stdout == nil {
+		p.cmd.Stdout = &stdout
+	} else {
+		p.cmd.Stdout = io.MultiWriter(p.cmd.Stdout, &stdout)
+	}
+
+	p.Start()
+	if !p.hasStarted() {
+		return nil, &DecodeError{Command: p.commandString(), Format: format, Err: p.Err()}
+	}
+	p.Wait()
+	return stdout.Bytes(), nil
+}
+
+// decodeError returns a *DecodeError for the process with the decoding error err
+func (p *Proc) decodeError(format string, err error) *DecodeError {
+	decErr := &DecodeError{Command: p.commandString(), Format: format, Err: err}
+	if exitErr := p.Err(); exitErr != nil {
+		decErr.ExitErr = exitErr
+		decErr.Stderr = strings.TrimSpace(p.Output())
+	}
+	return decErr
+}
+
+// exitError returns a *DecodeError if the process failed, or nil otherwise
+func (p *Proc) exitError(format string) error {
+	if p.Err() == nil {
+		return nil
+	}
+	return p.decodeError(format, nil)
+}
+
+func (p *Proc) commandString() string {
+	return Quote(p.cmd.Args...)
+}
+
+// splitLines splits data into lines, without line terminators
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// position returns the 1-based line and column of offset in data
+func position(data []byte, offset int64) (line, column int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,161 @@
+//go:build !windows
+
+package exec
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcJSON(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	tests := []struct {
+		name     string
+		cmdStr   string
+		expected []item
+		line     int
+		column   int
+		exitErr  bool
+	}{
+		{
+			name:     "stdout only",
+			cmdStr:   `/bin/sh -c 'echo warning >&2; echo "[{\"name\":\"a\",\"count\":1},{\"name\":\"b\",\"count\":2}]"'`,
+			expected: []item{{Name: "a", Count: 1}, {Name: "b", Count: 2}},
+		},
+		{
+			name:   "syntax error",
+			cmdStr: `/bin/sh -c 'printf "[\n  {\"name\": \"a\",,}\n]"'`,
+			line:   2,
+			column: 17,
+		},
+		{
+			name:   "type error",
+			cmdStr: `/bin/sh -c 'echo "[{\"name\": \"a\", \"count\": \"one\"}]"'`,
+			line:   1,
+			column: 30,
+		},
+		{
+			name:    "command failure",
+			cmdStr:  `/bin/sh -c 'echo not found >&2; exit 3'`,
+			line:    1,
+			column:  1,
+			exitErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var items []item
+			err := NewProc(test.cmdStr).JSON(&items)
+			if test.line == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(items, test.expected) {
+					t.Errorf("expecting %v, got %v", test.expected, items)
+				}
+				return
+			}
+
+			var decErr *DecodeError
+			if !errors.As(err, &decErr) {
+				t.Fatalf("expecting *DecodeError, got %v", err)
+			}
+			if decErr.Line != test.line || decErr.Column != test.column {
+				t.Errorf("expecting line %d column %d, got: %s", test.line, test.column, err)
+			}
+			if !strings.Contains(err.Error(), "/bin/sh") {
+				t.Errorf("expecting command in error: %s", err)
+			}
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) != test.exitErr {
+				t.Errorf("unexpected exit error: %s", err)
+			}
+			if test.exitErr && decErr.Stderr != "not found" {
+				t.Errorf("expecting stderr in error: %s", err)
+			}
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	v, err := RunJSON[map[string]int](`echo '{"a": 1, "b": 2}'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != 1 || v["b"] != 2 {
+		t.Errorf("unexpected value: %v", v)
+	}
+
+	if err := RunProc("echo hello").JSON(&v); err == nil {
+		t.Error("expecting error when process already started")
+	}
+}
+
+func TestProcDecodeStartError(t *testing.T) {
+	_, err := NewProc("gexe-no-such-command --json").Lines()
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expecting *DecodeError, got %v", err)
+	}
+	if decErr.Format != "lines" || decErr.Err == nil {
+		t.Errorf("unexpected decode error: %+v", decErr)
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("expecting wrapped start error, got %v", err)
+	}
+}
+
+func TestProcCSV(t *testing.T) {
+	records, err := NewProc(`printf 'name,count\na,1\n"b, c",2\n'`).CSV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"name", "count"}, {"a", "1"}, {"b, c", "2"}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expecting %v, got %v", expected, records)
+	}
+
+	_, err = NewProc(`printf 'a,b\nc,"d\n'`).CSV()
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) || decErr.Line != 2 {
+		t.Errorf("expecting decode error at line 2, got %v", err)
+	}
+}
+
+func TestProcLinesFields(t *testing.T) {
+	lines, err := NewProc(`printf 'a b\n\nc  d e\n'`).Lines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lines, []string{"a b", "", "c  d e"}) {
+		t.Errorf("unexpected lines: %#v", lines)
+	}
+
+	fields, err := NewProc(`printf 'a b\n\nc  d e\n'`).Fields("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fields, [][]string{{"a", "b"}, {"c", "d", "e"}}) {
+		t.Errorf("unexpected fields: %#v", fields)
+	}
+
+	fields, err = NewProc(`printf 'root:x:0\nbin:x:1\n'`).Fields(":")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fields, [][]string{{"root", "x", "0"}, {"bin", "x", "1"}}) {
+		t.Errorf("unexpected fields: %#v", fields)
+	}
+
+	if _, err := NewProc(`/bin/sh -c "exit 1"`).Lines(); err == nil {
+		t.Error("expecting error for failed command")
+	}
+}
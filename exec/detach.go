@@ -0,0 +1,123 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// DetachOptions configures a process started with StartDetached
+type DetachOptions struct {
+	// PidFile is the path of the file where the process id is written
+	PidFile string
+	// Stdout is the path of the file where the process output is appended (default: os.DevNull)
+	Stdout string
+	// Stderr is the path of the file where the process errors are appended (default: same as Stdout)
+	Stderr string
+	// WorkDir is the working directory of the process
+	WorkDir string
+}
+
+// DetachedProc stores information about a process started with StartDetached
+type DetachedProc struct {
+	pid     int
+	pidFile string
+	err     error
+}
+
+// ID returns the process id
+func (d *DetachedProc) ID() int {
+	return d.pid
+}
+
+// PidFile returns the path of the pid file
+func (d *DetachedProc) PidFile() string {
+	return d.pidFile
+}
+
+// Err returns any error encountered while starting the process
+func (d *DetachedProc) Err() error {
+	return d.err
+}
+
+// PidStatus reports the status of a process recorded in a pid file
+type PidStatus struct {
+	pid       int
+	startTime uint64
+	running   bool
+	err       error
+}
+
+// ID returns the process id recorded in the pid file
+func (s *PidStatus) ID() int {
+	return s.pid
+}
+
+// StartTime returns the recorded process start time (in clock ticks since boot)
+// or 0 if it is not supported on the platform.
+func (s *PidStatus) StartTime() uint64 {
+	return s.startTime
+}
+
+// IsRunning returns true if the recorded process is still running and, where supported,
+// is the same process that was started (i.e. its pid was not reused).
+func (s *PidStatus) IsRunning() bool {
+	return s.running
+}
+
+// Err returns any error encountered while reading the pid file
+func (s *PidStatus) Err() error {
+	return s.err
+}
+
+// StartDetached starts cmdStr as a process that is fully detached from the running program
+// (see StartDetachedWithVars).
+func StartDetached(cmdStr string, opts DetachOptions) *DetachedProc {
+	return StartDetachedWithVars(cmdStr, opts, vars.New())
+}
+
+// PidFileStatus reads the pid file and reports whether the recorded process is running
+func PidFileStatus(pidFile string) *PidStatus {
+	pid, startTime, err := readPidFile(pidFile)
+	if err != nil {
+		return &PidStatus{err: err}
+	}
+	return &PidStatus{pid: pid, startTime: startTime, running: isSameProcess(pid, startTime)}
+}
+
+// writePidFile writes the pid and its start time (if known) to pidFile
+func writePidFile(pidFile string, pid int, startTime uint64) error {
+	if err := os.MkdirAll(filepath.Dir(pidFile), 0755); err != nil {
+		return err
+	}
+	content := fmt.Sprintf("%d\n%d\n", pid, startTime)
+	return os.WriteFile(pidFile, []byte(content), 0644)
+}
+
+// readPidFile reads the pid and start time from pidFile
+func readPidFile(pidFile string) (int, uint64, error) {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("pid file %s: empty", pidFile)
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("pid file %s: invalid pid: %w", pidFile, err)
+	}
+	var startTime uint64
+	if len(fields) > 1 {
+		startTime, err = strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("pid file %s: invalid start time: %w", pidFile, err)
+		}
+	}
+	return pid, startTime, nil
+}
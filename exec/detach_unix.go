@@ -0,0 +1,161 @@
+//go:build !windows
+
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/vladimirvivien/gexe/procs"
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// StartDetachedWithVars starts cmdStr, expanded with the session variables, as a process that is
+// fully detached from the running program: it runs in a new session (setsid), its stdin is
+// os.DevNull and its output is appended to the log files in opts. The process keeps running
+// after the program exits. If opts.PidFile is set, the process id and its start time are
+// recorded in the file to be used with PidFileStatus and StopPidFile.
+func StartDetachedWithVars(cmdStr string, opts DetachOptions, variables *vars.Variables) *DetachedProc {
+	proc := NewProcWithContextVars(context.Background(), cmdStr, variables)
+	if err := proc.Err(); err != nil {
+		return &DetachedProc{err: err}
+	}
+
+	stdoutPath := os.DevNull
+	if opts.Stdout != "" {
+		stdoutPath = variables.Eval(opts.Stdout)
+	}
+	stderrPath := stdoutPath
+	if opts.Stderr != "" {
+		stderrPath = variables.Eval(opts.Stderr)
+	}
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		return &DetachedProc{err: err}
+	}
+	defer stdin.Close()
+
+	stdout, err := os.OpenFile(stdoutPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return &DetachedProc{err: err}
+	}
+	defer stdout.Close()
+
+	stderr := stdout
+	if stderrPath != stdoutPath {
+		stderr, err = os.OpenFile(stderrPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return &DetachedProc{err: err}
+		}
+		defer stderr.Close()
+	}
+
+	proc.cmd.Stdin = stdin
+	proc.cmd.Stdout = stdout
+	proc.cmd.Stderr = stderr
+	if opts.WorkDir != "" {
+		proc.cmd.Dir = variables.Eval(opts.WorkDir)
+	}
+	if err := proc.applyCredentials(); err != nil {
+		return &DetachedProc{err: err}
+	}
+	if proc.cmd.SysProcAttr == nil {
+		proc.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	proc.cmd.SysProcAttr.Setsid = true
+
+	if err := proc.cmd.Start(); err != nil {
+		return &DetachedProc{err: err}
+	}
+	pid := proc.cmd.Process.Pid
+
+	// reap the process if it exits while the program is still running
+	go func() { _ = proc.cmd.Wait() }()
+
+	detached := &DetachedProc{pid: pid}
+	if opts.PidFile != "" {
+		detached.pidFile = variables.Eval(opts.PidFile)
+		var startTime uint64
+		if info, err := procs.Get(pid); err == nil {
+			startTime = info.StartTicks
+		}
+		if err := writePidFile(detached.pidFile, pid, startTime); err != nil {
+			detached.err = err
+		}
+	}
+	return detached
+}
+
+// StopPidFile stops the process recorded in pidFile. It sends SIGTERM to the process group
+// and, if the process is still running after grace, sends SIGKILL. The pid file is removed
+// once the process is stopped. A stale pid file (process no longer running) is removed
+// without signaling the recorded pid.
+func StopPidFile(pidFile string, grace time.Duration) error {
+	status := PidFileStatus(pidFile)
+	if err := status.Err(); err != nil {
+		return err
+	}
+
+	if status.IsRunning() {
+		if err := signalGroup(status.ID(), syscall.SIGTERM); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(grace)
+		killed := false
+		for isSameProcess(status.ID(), status.StartTime()) {
+			if !killed && time.Now().After(deadline) {
+				if err := signalGroup(status.ID(), syscall.SIGKILL); err != nil {
+					return err
+				}
+				killed = true
+				deadline = time.Now().Add(5 * time.Second)
+			}
+			if killed && time.Now().After(deadline) {
+				return fmt.Errorf("process %d still running after kill", status.ID())
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	if err := os.Remove(pidFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// signalGroup signals the process group led by pid, falling back to the process itself
+func signalGroup(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	if err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("signal %d: %w", pid, err)
+	}
+	return nil
+}
+
+// isSameProcess returns true if pid is running and, where supported,
+// was started at startTime.
+func isSameProcess(pid int, startTime uint64) bool {
+	if pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	info, err := procs.Get(pid)
+	if err != nil {
+		// start time is not available on this platform
+		return errors.Is(err, procs.ErrUnsupported)
+	}
+	if info.State == "Z" || info.State == "X" {
+		return false
+	}
+	return startTime == 0 || info.StartTicks == startTime
+}
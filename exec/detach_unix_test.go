@@ -0,0 +1,93 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartDetached(t *testing.T) {
+	tests := []struct {
+		name   string
+		cmdStr string
+		grace  time.Duration
+	}{
+		{
+			name:   "stop on SIGTERM",
+			cmdStr: `/bin/sh -c "echo started; sleep 10"`,
+			grace:  2 * time.Second,
+		},
+		{
+			name:   "kill after grace",
+			cmdStr: `/bin/sh -c "trap '' TERM; echo started; sleep 10"`,
+			grace:  200 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pidFile := filepath.Join(dir, "run", "test.pid")
+			logFile := filepath.Join(dir, "test.log")
+
+			detached := StartDetached(test.cmdStr, DetachOptions{PidFile: pidFile, Stdout: logFile})
+			if err := detached.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			status := PidFileStatus(pidFile)
+			if err := status.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if status.ID() != detached.ID() {
+				t.Errorf("expecting pid %d, got %d", detached.ID(), status.ID())
+			}
+			if !status.IsRunning() {
+				t.Fatal("expecting detached process to be running")
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				data, _ := os.ReadFile(logFile)
+				if strings.TrimSpace(string(data)) == "started" {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("unexpected log content: %q", string(data))
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			if err := StopPidFile(pidFile, test.grace); err != nil {
+				t.Fatal(err)
+			}
+			if isSameProcess(status.ID(), status.StartTime()) {
+				t.Error("expecting detached process to be stopped")
+			}
+			if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+				t.Error("expecting pid file to be removed")
+			}
+		})
+	}
+}
+
+func TestPidFileStatus_Stale(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "stale.pid")
+	proc := RunProc("true")
+	if err := writePidFile(pidFile, proc.ID(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if PidFileStatus(pidFile).IsRunning() {
+		t.Error("expecting stale pid file to report not running")
+	}
+	if err := StopPidFile(pidFile, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if PidFileStatus(pidFile).Err() == nil {
+		t.Error("expecting error for removed pid file")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package exec
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+var errDetachUnsupported = errors.New("detached processes are not supported on windows")
+
+// StartDetachedWithVars is not supported on Windows
+func StartDetachedWithVars(cmdStr string, opts DetachOptions, variables *vars.Variables) *DetachedProc {
+	return &DetachedProc{err: errDetachUnsupported}
+}
+
+// StopPidFile is not supported on Windows
+func StopPidFile(pidFile string, grace time.Duration) error {
+	return errDetachUnsupported
+}
+
+// isSameProcess is not supported on Windows
+func isSameProcess(pid int, startTime uint64) bool {
+	return false
+}
@@ -0,0 +1,316 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// DefaultForEachVar is the name of the variable bound to each item
+const DefaultForEachVar = "item"
+
+// forEachPlaceholder stands for the items in the parsed command template
+const forEachPlaceholder = "\x00gexe-foreach-items\x00"
+
+// ForEachBuilder runs a command template, xargs-style, for each item (or batch of items)
+// from a list or a stream. The template is expanded and parsed once, then each item is
+// placed, as-is, where the item variable (default ${item}) is referenced. Items are never
+// split, unquoted, or expanded: a reference that makes up a whole argument is replaced
+// with one argument per item, otherwise the items are joined with a space in the argument.
+type ForEachBuilder struct {
+	ctx         context.Context
+	vars        *vars.Variables
+	cmdStr      string
+	items       []string
+	reader      io.Reader
+	sourceProc  *Proc
+	varName     string
+	concurrency int
+	batchSize   int
+	ordered     bool
+	policy      CommandPolicy
+	procHook    func(*Proc)
+	allowCodes  []int
+}
+
+// ForEachWithContextVars creates a *ForEachBuilder that runs cmdStr for each item in items
+// using the specified context and session variables.
+func ForEachWithContextVars(ctx context.Context, variables *vars.Variables, items []string, cmdStr string) *ForEachBuilder {
+	return &ForEachBuilder{
+		ctx:         ctx,
+		vars:        variables,
+		cmdStr:      cmdStr,
+		items:       items,
+		varName:     DefaultForEachVar,
+		concurrency: 1,
+		batchSize:   1,
+	}
+}
+
+// ForEach creates a *ForEachBuilder that runs cmdStr for each item in items
+func ForEach(items []string, cmdStr string) *ForEachBuilder {
+	return ForEachWithContextVars(context.Background(), vars.New(), items, cmdStr)
+}
+
+// ForEachReaderWithContextVars creates a *ForEachBuilder that runs cmdStr for each
+// non-empty line read from reader, using the specified context and session variables.
+func ForEachReaderWithContextVars(ctx context.Context, variables *vars.Variables, reader io.Reader, cmdStr string) *ForEachBuilder {
+	fb := ForEachWithContextVars(ctx, variables, nil, cmdStr)
+	fb.reader = reader
+	return fb
+}
+
+// ForEachReader creates a *ForEachBuilder that runs cmdStr for each non-empty line read from reader
+func ForEachReader(reader io.Reader, cmdStr string) *ForEachBuilder {
+	return ForEachReaderWithContextVars(context.Background(), vars.New(), reader, cmdStr)
+}
+
+// ForEachProcWithContextVars creates a *ForEachBuilder that runs cmdStr for each
+// non-empty line written to stdout by proc. The proc must not be started,
+// it is started and waited on by ForEachBuilder.Run.
+func ForEachProcWithContextVars(ctx context.Context, variables *vars.Variables, proc *Proc, cmdStr string) *ForEachBuilder {
+	fb := ForEachWithContextVars(ctx, variables, nil, cmdStr)
+	fb.sourceProc = proc
+	return fb
+}
+
+// ForEachProc creates a *ForEachBuilder that runs cmdStr for each non-empty line written to stdout by proc
+func ForEachProc(proc *Proc, cmdStr string) *ForEachBuilder {
+	return ForEachProcWithContextVars(context.Background(), vars.New(), proc, cmdStr)
+}
+
+// WithVarName sets the name of the variable bound to each item (default: item)
+func (fb *ForEachBuilder) WithVarName(name string) *ForEachBuilder {
+	fb.varName = name
+	return fb
+}
+
+// WithConcurrency sets the maximum number of commands running at once (default: 1)
+func (fb *ForEachBuilder) WithConcurrency(n int) *ForEachBuilder {
+	if n > 0 {
+		fb.concurrency = n
+	}
+	return fb
+}
+
+// WithBatchSize sets the number of items passed to each command invocation (default: 1).
+// Items in a batch are passed as separate arguments, or joined with a space when the item
+// variable is part of a larger argument.
+func (fb *ForEachBuilder) WithBatchSize(n int) *ForEachBuilder {
+	if n > 0 {
+		fb.batchSize = n
+	}
+	return fb
+}
+
+// WithOrdered makes CommandResult.Procs report processes in input order
+// instead of completion order.
+func (fb *ForEachBuilder) WithOrdered() *ForEachBuilder {
+	fb.ordered = true
+	return fb
+}
+
+// WithPolicy sets the command policy. If ExitOnErrPolicy is set, no new
+// command is started after the first failure.
+func (fb *ForEachBuilder) WithPolicy(policy CommandPolicy) *ForEachBuilder {
+	fb.policy = policy
+	return fb
+}
+
+// AllowExitCodes sets non-zero exit codes that are accepted as successful (see Proc.AllowExitCodes)
+func (fb *ForEachBuilder) AllowExitCodes(codes ...int) *ForEachBuilder {
+	fb.allowCodes = append(fb.allowCodes, codes...)
+	return fb
+}
+
+// WithProcHook sets a function that is called with each process before it is started
+func (fb *ForEachBuilder) WithProcHook(hook func(*Proc)) *ForEachBuilder {
+	fb.procHook = hook
+	return fb
+}
+
+type forEachJob struct {
+	index int
+	items []string
+}
+
+type forEachProc struct {
+	index int
+	proc  *Proc
+}
+
+// Run executes the command for all items and waits for their completion.
+func (fb *ForEachBuilder) Run() *CommandResult {
+	result := new(CommandResult)
+
+	template, err := fb.template()
+	if err != nil {
+		result.err = fmt.Errorf("foreach: %w", err)
+		return result
+	}
+
+	jobs := make(chan forEachJob)
+	var (
+		mu     sync.Mutex
+		done   []forEachProc
+		failed bool
+		wg     sync.WaitGroup
+	)
+
+	stopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed && hasPolicy(fb.policy, ExitOnErrPolicy)
+	}
+
+	for i := 0; i < fb.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if stopped() {
+					continue
+				}
+				proc := fb.newProc(template, job.items)
+				if fb.procHook != nil {
+					fb.procHook(proc)
+				}
+				err := proc.Run().Err()
+
+				mu.Lock()
+				done = append(done, forEachProc{index: job.index, proc: proc})
+				if err != nil {
+					failed = true
+					result.errProcs = append(result.errProcs, proc)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	result.err = fb.dispatch(jobs, stopped)
+	close(jobs)
+	wg.Wait()
+
+	if fb.ordered {
+		sort.Slice(done, func(i, j int) bool { return done[i].index < done[j].index })
+	}
+	for _, p := range done {
+		result.procs = append(result.procs, p.proc)
+	}
+	return result
+}
+
+// dispatch sends batches of items, from the builder's source, to the jobs channel
+// until all items are sent or stopped returns true
+func (fb *ForEachBuilder) dispatch(jobs chan<- forEachJob, stopped func() bool) error {
+	index := 0
+	var batch []string
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		jobs <- forEachJob{index: index, items: batch}
+		index++
+		batch = nil
+	}
+
+	reader := fb.reader
+	if fb.sourceProc != nil {
+		if err := fb.sourceProc.Err(); err != nil {
+			return err
+		}
+		pipe, err := fb.sourceProc.cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := fb.sourceProc.Start().Err(); err != nil {
+			return err
+		}
+		reader = pipe
+	}
+
+	if reader == nil {
+		for _, item := range fb.items {
+			if stopped() {
+				return nil
+			}
+			batch = append(batch, item)
+			if len(batch) == fb.batchSize {
+				send()
+			}
+		}
+		send()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for !stopped() && scanner.Scan() {
+		item := strings.TrimSpace(scanner.Text())
+		if item == "" {
+			continue
+		}
+		batch = append(batch, item)
+		if len(batch) == fb.batchSize {
+			send()
+		}
+	}
+	if !stopped() {
+		send()
+	}
+
+	scanErr := scanner.Err()
+	if fb.sourceProc != nil {
+		// the source process is not read to the end, kill it before it is waited on
+		aborted := scanErr != nil || stopped()
+		if aborted {
+			_ = fb.sourceProc.Signal(os.Kill)
+		}
+		if err := fb.sourceProc.Wait().Err(); err != nil && !aborted {
+			return fmt.Errorf("foreach: source process: %w", err)
+		}
+	}
+	if scanErr != nil {
+		return fmt.Errorf("foreach: reading items: %w", scanErr)
+	}
+	return nil
+}
+
+// template expands and parses the command string, with the item variable
+// bound to a placeholder, into the arguments of the command template
+func (fb *ForEachBuilder) template() ([]string, error) {
+	variables := fb.vars
+	if variables == nil {
+		variables = vars.New()
+	}
+	scope := variables.Child().SetVar(fb.varName, forEachPlaceholder)
+	args, err := parse(scope.ExpandCommand(fb.cmdStr))
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// newProc creates a process from the command template with the placeholder replaced by items
+func (fb *ForEachBuilder) newProc(template, items []string) *Proc {
+	var args []string
+	for _, arg := range template {
+		if arg == forEachPlaceholder {
+			args = append(args, items...)
+			continue
+		}
+		args = append(args, strings.ReplaceAll(arg, forEachPlaceholder, strings.Join(items, " ")))
+	}
+	return NewProcArgs(fb.ctx, args[0], args[1:]...).SetVars(fb.vars).AllowExitCodes(fb.allowCodes...)
+}
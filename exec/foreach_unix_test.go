@@ -0,0 +1,216 @@
+//go:build !windows
+
+package exec
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func() *ForEachBuilder
+		results []string
+		errs    int
+		ordered bool
+	}{
+		{
+			name: "items in order",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"a", "b", "c"}, "echo ${item}")
+			},
+			results: []string{"a", "b", "c"},
+			ordered: true,
+		},
+		{
+			name: "session variables and item",
+			builder: func() *ForEachBuilder {
+				v := vars.New().SetVar("prefix", "file")
+				return ForEachWithContextVars(context.Background(), v, []string{"a", "b"}, "echo ${prefix}-${item}")
+			},
+			results: []string{"file-a", "file-b"},
+			ordered: true,
+		},
+		{
+			name: "items with spaces, quotes, and variables",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"my file.txt", "a'b", `say "hi"`, "$HOME"}, "printf [%s] ${item}")
+			},
+			results: []string{"[my file.txt]", "[a'b]", `[say "hi"]`, "[$HOME]"},
+			ordered: true,
+		},
+		{
+			name: "item within an argument",
+			builder: func() *ForEachBuilder {
+				v := vars.New().SetVar("dir", "/tmp")
+				return ForEachWithContextVars(context.Background(), v, []string{"a b", "${dir}"}, `printf [%s] "${dir}/${item}.txt"`)
+			},
+			results: []string{"[/tmp/a b.txt]", "[/tmp/${dir}.txt]"},
+			ordered: true,
+		},
+		{
+			name: "custom var name",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"x"}, "echo ${name}").WithVarName("name")
+			},
+			results: []string{"x"},
+			ordered: true,
+		},
+		{
+			name: "batches",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"a", "b", "c", "d", "e"}, "echo ${item}").WithBatchSize(2)
+			},
+			results: []string{"a b", "c d", "e"},
+			ordered: true,
+		},
+		{
+			name: "batches with spaces",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"a b", "c", "d e"}, "printf [%s] ${item}").WithBatchSize(2)
+			},
+			results: []string{"[a b][c]", "[d e]"},
+			ordered: true,
+		},
+		{
+			name: "concurrent ordered",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"0.3", "0.1", "0.2"}, `/bin/sh -c "sleep ${item}; echo ${item}"`).
+					WithConcurrency(3).WithOrdered()
+			},
+			results: []string{"0.3", "0.1", "0.2"},
+			ordered: true,
+		},
+		{
+			name: "concurrent unordered",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"0.3", "0.1", "0.2"}, `/bin/sh -c "sleep ${item}; echo ${item}"`).
+					WithConcurrency(3)
+			},
+			results: []string{"0.1", "0.2", "0.3"},
+			ordered: true,
+		},
+		{
+			name: "reader items",
+			builder: func() *ForEachBuilder {
+				return ForEachReader(strings.NewReader("one\n\ntwo\nthree\n"), "echo ${item}")
+			},
+			results: []string{"one", "two", "three"},
+			ordered: true,
+		},
+		{
+			name: "proc output items",
+			builder: func() *ForEachBuilder {
+				return ForEachProc(NewProc(`/bin/sh -c "echo one; echo two"`), "echo item-${item}")
+			},
+			results: []string{"item-one", "item-two"},
+			ordered: true,
+		},
+		{
+			name: "errors continue",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"0", "1", "0"}, `/bin/sh -c "exit ${item}"`)
+			},
+			results: []string{"", "exit status 1", ""},
+			errs:    1,
+		},
+		{
+			name: "errors exit",
+			builder: func() *ForEachBuilder {
+				return ForEach([]string{"1", "0", "0"}, `/bin/sh -c "exit ${item}"`).WithPolicy(ExitOnErrPolicy)
+			},
+			results: []string{"exit status 1"},
+			errs:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.builder().Run()
+			if err := result.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(result.ErrProcs()) != test.errs {
+				t.Errorf("expecting %d failed procs, got %d", test.errs, len(result.ErrProcs()))
+			}
+			if len(result.Procs()) != len(test.results) {
+				t.Fatalf("expecting %d procs, got %d", len(test.results), len(result.Procs()))
+			}
+			if !test.ordered {
+				return
+			}
+			for i, proc := range result.Procs() {
+				if proc.Result() != test.results[i] {
+					t.Errorf("proc %d: expecting %q, got %q", i, test.results[i], proc.Result())
+				}
+			}
+		})
+	}
+}
+
+func TestForEachTemplateError(t *testing.T) {
+	result := ForEach([]string{"a"}, `echo "${item}`).Run()
+	if result.Err() == nil {
+		t.Fatal("expecting template parse error")
+	}
+	if len(result.Procs()) != 0 {
+		t.Errorf("expecting no procs, got %d", len(result.Procs()))
+	}
+}
+
+func TestForEachSourceProcStopped(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func(source *Proc) *ForEachBuilder
+		err     bool
+	}{
+		{
+			name: "exit on error",
+			builder: func(source *Proc) *ForEachBuilder {
+				return ForEachProc(source, "false").WithPolicy(ExitOnErrPolicy)
+			},
+		},
+		{
+			name: "read error",
+			builder: func(*Proc) *ForEachBuilder {
+				// a line longer than the scanner buffer fails reading
+				return ForEachProc(NewProc(`/bin/sh -c "printf %0100000d 0; echo; exec sleep 30"`), "echo ${item}")
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := NewProc("yes")
+			builder := test.builder(source)
+			start := time.Now()
+			result := builder.Run()
+			if (result.Err() != nil) != test.err {
+				t.Errorf("unexpected error: %v", result.Err())
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("source process was not stopped: %s", elapsed)
+			}
+			if builder.sourceProc.Command().ProcessState == nil {
+				t.Error("expecting source process to be waited on")
+			}
+		})
+	}
+}
+
+func TestForEachConcurrency(t *testing.T) {
+	start := time.Now()
+	result := ForEach([]string{"1", "2", "3", "4"}, `/bin/sh -c "sleep 0.3"`).WithConcurrency(4).Run()
+	if len(result.ErrProcs()) != 0 {
+		t.Fatal(result.Errs())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("items did not run concurrently: %s", elapsed)
+	}
+}
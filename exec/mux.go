@@ -0,0 +1,143 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ANSI colors assigned, in order, to the labels of an OutputMux
+var muxColors = []string{"\033[36m", "\033[33m", "\033[32m", "\033[35m", "\033[34m", "\033[91m", "\033[96m", "\033[93m"}
+
+const muxColorReset = "\033[0m"
+
+// OutputMux multiplexes the output of several processes onto a single writer.
+// Output is line-buffered, so lines from different processes never interleave, and
+// each line is prefixed with a label identifying its process (similar to docker-compose logs):
+//
+//	web  | listening on :8080
+//	db   | ready to accept connections
+//
+// An OutputMux is safe for concurrent use.
+type OutputMux struct {
+	mu         sync.Mutex
+	out        io.Writer
+	color      bool
+	timeLayout string
+	width      int
+	labels     map[string]int
+	colors     map[string]string
+}
+
+// NewOutputMux returns an *OutputMux that writes prefixed lines to out
+func NewOutputMux(out io.Writer) *OutputMux {
+	return &OutputMux{out: out, labels: make(map[string]int), colors: make(map[string]string)}
+}
+
+// WithColor enables or disables coloring of line prefixes. Each label is assigned its own color.
+func (m *OutputMux) WithColor(color bool) *OutputMux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.color = color
+	return m
+}
+
+// WithTimestamps prefixes each line with its time formatted using layout (i.e. time.TimeOnly).
+// An empty layout disables timestamps.
+func (m *OutputMux) WithTimestamps(layout string) *OutputMux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeLayout = layout
+	return m
+}
+
+// Writer returns a line-buffered writer whose lines are written to the mux prefixed with label.
+// If label is already in use, a numeric suffix is appended to keep labels distinct.
+// The writer must be closed to flush a trailing line that is not newline-terminated.
+func (m *OutputMux) Writer(label string) io.WriteCloser {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.labels[label]++
+	if count := m.labels[label]; count > 1 {
+		label = fmt.Sprintf("%s-%d", label, count)
+	}
+	m.width = max(m.width, len(label))
+	m.colors[label] = muxColors[(len(m.colors))%len(muxColors)]
+
+	return &muxWriter{mux: m, label: label}
+}
+
+// writeLine writes a single line, with its prefix, to the underlying writer
+func (m *OutputMux) writeLine(label string, line []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var prefix strings.Builder
+	if m.timeLayout != "" {
+		prefix.WriteString(time.Now().Format(m.timeLayout))
+		prefix.WriteByte(' ')
+	}
+	if m.color {
+		prefix.WriteString(m.colors[label])
+	}
+	fmt.Fprintf(&prefix, "%-*s |", m.width, label)
+	if m.color {
+		prefix.WriteString(muxColorReset)
+	}
+	if len(line) > 1 {
+		prefix.WriteByte(' ')
+	}
+
+	_, err := m.out.Write(append([]byte(prefix.String()), line...))
+	return err
+}
+
+// muxWriter buffers writes until a complete line is available
+type muxWriter struct {
+	mu    sync.Mutex
+	mux   *OutputMux
+	label string
+	buf   bytes.Buffer
+}
+
+// Write buffers data and writes each complete line to the mux
+func (w *muxWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(data)
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx < 0 {
+			return len(data), nil
+		}
+		if err := w.mux.writeLine(w.label, w.buf.Next(idx+1)); err != nil {
+			return len(data), err
+		}
+	}
+}
+
+// Close flushes the remaining partial line, if any, terminated with a newline
+func (w *muxWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := append(w.buf.Bytes(), '\n')
+	w.buf.Reset()
+	return w.mux.writeLine(w.label, line)
+}
+
+// procLabel returns the label used for a process in an OutputMux
+func procLabel(proc *Proc) string {
+	if proc.cmd == nil || len(proc.cmd.Args) == 0 {
+		return "proc"
+	}
+	return filepath.Base(proc.cmd.Args[0])
+}
@@ -0,0 +1,103 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOutputMux(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    bool
+		writes   func(mux *OutputMux)
+		expected string
+	}{
+		{
+			name: "line buffered",
+			writes: func(mux *OutputMux) {
+				web, db := mux.Writer("web"), mux.Writer("db")
+				io.WriteString(web, "listen")
+				io.WriteString(db, "ready\nwaiting")
+				io.WriteString(web, "ing on :8080\n\n")
+				db.Close()
+			},
+			expected: "db  | ready\nweb | listening on :8080\nweb |\ndb  | waiting\n",
+		},
+		{
+			name: "duplicate labels",
+			writes: func(mux *OutputMux) {
+				io.WriteString(mux.Writer("echo"), "a\n")
+				io.WriteString(mux.Writer("echo"), "b\n")
+			},
+			expected: "echo | a\necho-2 | b\n",
+		},
+		{
+			name:  "colors",
+			color: true,
+			writes: func(mux *OutputMux) {
+				io.WriteString(mux.Writer("a"), "x\n")
+				io.WriteString(mux.Writer("b"), "y\n")
+			},
+			expected: muxColors[0] + "a |" + muxColorReset + " x\n" + muxColors[1] + "b |" + muxColorReset + " y\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			mux := NewOutputMux(&out).WithColor(test.color)
+			test.writes(mux)
+			if out.String() != test.expected {
+				t.Errorf("expecting:\n%q\ngot:\n%q", test.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestOutputMux_Timestamps(t *testing.T) {
+	var out bytes.Buffer
+	w := NewOutputMux(&out).WithTimestamps("2006").Writer("app")
+	io.WriteString(w, "hello\n")
+	if !strings.HasSuffix(out.String(), " app | hello\n") || len(out.String()) != len("2006 app | hello\n") {
+		t.Errorf("unexpected timestamped line: %q", out.String())
+	}
+}
+
+func TestOutputMux_Concurrent(t *testing.T) {
+	var out bytes.Buffer
+	mux := NewOutputMux(&out)
+	var wg sync.WaitGroup
+	for i := range 4 {
+		w := mux.Writer(fmt.Sprintf("w%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.Close()
+			for j := range 100 {
+				// write each line in two parts to exercise line buffering
+				fmt.Fprintf(w, "line-%d-", i)
+				fmt.Fprintf(w, "%d\n", j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("expecting 400 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var label string
+		var i, j, k int
+		if _, err := fmt.Sscanf(line, "%s | line-%d-%d", &label, &j, &k); err != nil {
+			t.Fatalf("interleaved line %q: %s", line, err)
+		}
+		if _, err := fmt.Sscanf(label, "w%d", &i); err != nil || i != j {
+			t.Fatalf("line %q written with wrong label", line)
+		}
+	}
+}
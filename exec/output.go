@@ -0,0 +1,238 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// CapturePolicy determines how much of a process' combined output is kept in memory
+type CapturePolicy int
+
+const (
+	// CaptureAll keeps all output in memory (default)
+	CaptureAll CapturePolicy = iota
+	// CaptureHead keeps the first N bytes of output and drops the rest
+	CaptureHead
+	// CaptureTail keeps the last N bytes of output (ring buffer) and drops older output
+	CaptureTail
+	// CaptureSpill keeps the first N bytes in memory and writes the rest to a temporary file
+	CaptureSpill
+)
+
+// String returns the name of the capture policy
+func (c CapturePolicy) String() string {
+	switch c {
+	case CaptureAll:
+		return "all"
+	case CaptureHead:
+		return "head"
+	case CaptureTail:
+		return "tail"
+	case CaptureSpill:
+		return "spill"
+	}
+	return "unknown"
+}
+
+// outputBuffer is a bytes.Buffer that is safe for concurrent use. It allows
+// the output of a running process to be read while it is being written.
+// The amount of output retained is controlled by its capture policy.
+type outputBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	policy  CapturePolicy
+	limit   int
+	dropped int64
+	written int64
+
+	// spill file state (CaptureSpill)
+	spill     *os.File
+	spillName string
+	spillSize int64
+	spillRead int64
+}
+
+func newOutputBuffer() *outputBuffer {
+	return new(outputBuffer)
+}
+
+// setPolicy sets the capture policy and its size limit in bytes
+func (b *outputBuffer) setPolicy(policy CapturePolicy, limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit <= 0 {
+		policy = CaptureAll
+	}
+	b.policy, b.limit = policy, limit
+}
+
+// Policy returns the capture policy of the buffer
+func (b *outputBuffer) Policy() CapturePolicy {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.policy
+}
+
+// Write appends data to the buffer according to the capture policy. It always reports
+// the full length of data as written so that the process is never blocked by capture limits.
+func (b *outputBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := len(data)
+	b.written += int64(size)
+	switch b.policy {
+	case CaptureHead:
+		room := max(b.limit-b.buf.Len(), 0)
+		kept := min(room, len(data))
+		b.buf.Write(data[:kept])
+		b.dropped += int64(len(data) - kept)
+	case CaptureTail:
+		if len(data) >= b.limit {
+			b.dropped += int64(b.buf.Len() + len(data) - b.limit)
+			b.buf.Reset()
+			b.buf.Write(data[len(data)-b.limit:])
+			break
+		}
+		if over := b.buf.Len() + len(data) - b.limit; over > 0 {
+			b.buf.Next(over)
+			b.dropped += int64(over)
+		}
+		b.buf.Write(data)
+	case CaptureSpill:
+		if b.spill == nil {
+			room := max(b.limit-b.buf.Len(), 0)
+			if len(data) <= room {
+				return b.buf.Write(data)
+			}
+			b.buf.Write(data[:room])
+			data = data[room:]
+			spill, err := os.CreateTemp("", "gexe-output-*")
+			if err != nil {
+				b.dropped += int64(len(data))
+				break
+			}
+			b.spill, b.spillName = spill, spill.Name()
+		}
+		n, err := b.spill.WriteAt(data, b.spillSize)
+		b.spillSize += int64(n)
+		if err != nil {
+			b.dropped += int64(len(data) - n)
+		}
+	default:
+		return b.buf.Write(data)
+	}
+	return size, nil
+}
+
+// Read reads (and consumes) data from the buffer, followed by spilled data if any
+func (b *outputBuffer) Read(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf.Len() > 0 || b.spill == nil {
+		return b.buf.Read(data)
+	}
+	if b.spillRead >= b.spillSize {
+		return 0, io.EOF
+	}
+	size := min(int64(len(data)), b.spillSize-b.spillRead)
+	n, err := b.spill.ReadAt(data[:size], b.spillRead)
+	b.spillRead += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// String returns the unread content of the buffer, including spilled data.
+// Spilled data is read from disk, in full, on every call.
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spill == nil || b.spillRead >= b.spillSize {
+		return b.buf.String()
+	}
+	spilled := make([]byte, b.spillSize-b.spillRead)
+	n, _ := b.spill.ReadAt(spilled, b.spillRead)
+	return b.buf.String() + string(spilled[:n])
+}
+
+// Reader returns a reader of the unread content of the buffer, including spilled
+// data, at the time of the call. Unlike Read, it does not consume the content and
+// spilled data is streamed from disk instead of being loaded in memory.
+func (b *outputBuffer) Reader() io.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	buffered := bytes.NewReader(bytes.Clone(b.buf.Bytes()))
+	if b.spill == nil || b.spillRead >= b.spillSize {
+		return buffered
+	}
+	return io.MultiReader(buffered, io.NewSectionReader(b.spill, b.spillRead, b.spillSize-b.spillRead))
+}
+
+// Len returns the number of unread bytes in the buffer, including spilled data
+func (b *outputBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len() + int(b.spillSize-b.spillRead)
+}
+
+// Dropped returns the number of bytes discarded by the capture policy
+func (b *outputBuffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Written returns the total number of bytes written to the buffer, including dropped bytes
+func (b *outputBuffer) Written() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.written
+}
+
+// SpillFile returns the path of the spill file, if output was spilled to disk
+// and the file was not removed yet (see removeSpill)
+func (b *outputBuffer) SpillFile() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spillName
+}
+
+// removeSpill removes the spill file from disk while keeping it open, so spilled
+// data remains readable until Close. Removing an open file fails on Windows, where
+// the file is kept until Close.
+func (b *outputBuffer) removeSpill() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spillName == "" {
+		return
+	}
+	if err := os.Remove(b.spillName); err == nil {
+		b.spillName = ""
+	}
+}
+
+// Close removes the spill file, if any. Spilled output is no longer available afterward.
+func (b *outputBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spill == nil {
+		return nil
+	}
+	name := b.spillName
+	b.spill.Close()
+	b.spill, b.spillName, b.spillSize, b.spillRead = nil, "", 0, 0
+	if name == "" {
+		return nil
+	}
+	return os.Remove(name)
+}
+
+// truncationNotice returns the message reported when output was dropped
+func truncationNotice(dropped int64) string {
+	return fmt.Sprintf("[output truncated: %d bytes dropped]", dropped)
+}
@@ -0,0 +1,133 @@
+package exec
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   CapturePolicy
+		limit    int
+		writes   []string
+		expected string
+		dropped  int64
+		spilled  bool
+	}{
+		{name: "capture all", policy: CaptureAll, writes: []string{"hello ", "world"}, expected: "hello world"},
+		{name: "no limit", policy: CaptureHead, writes: []string{"hello ", "world"}, expected: "hello world"},
+		{name: "head within limit", policy: CaptureHead, limit: 20, writes: []string{"hello ", "world"}, expected: "hello world"},
+		{name: "head", policy: CaptureHead, limit: 8, writes: []string{"hello ", "world"}, expected: "hello wo", dropped: 3},
+		{name: "tail", policy: CaptureTail, limit: 8, writes: []string{"hello ", "world"}, expected: "lo world", dropped: 3},
+		{name: "tail large write", policy: CaptureTail, limit: 4, writes: []string{"ab", "hello world"}, expected: "orld", dropped: 9},
+		{name: "tail many writes", policy: CaptureTail, limit: 5, writes: []string{"a", "b", "c", "d", "e", "f", "g"}, expected: "cdefg", dropped: 2},
+		{name: "spill within limit", policy: CaptureSpill, limit: 20, writes: []string{"hello ", "world"}, expected: "hello world"},
+		{name: "spill", policy: CaptureSpill, limit: 4, writes: []string{"hello ", "world", "!"}, expected: "hello world!", spilled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := newOutputBuffer()
+			buf.setPolicy(test.policy, test.limit)
+			defer buf.Close()
+
+			for _, data := range test.writes {
+				n, err := buf.Write([]byte(data))
+				if err != nil || n != len(data) {
+					t.Fatalf("unexpected write result: %d, %v", n, err)
+				}
+			}
+
+			if buf.Len() != len(test.expected) {
+				t.Errorf("expecting length %d, got %d", len(test.expected), buf.Len())
+			}
+			if buf.String() != test.expected {
+				t.Errorf("expecting %q, got %q", test.expected, buf.String())
+			}
+			if buf.Dropped() != test.dropped {
+				t.Errorf("expecting %d dropped bytes, got %d", test.dropped, buf.Dropped())
+			}
+
+			spillFile := buf.SpillFile()
+			if (spillFile != "") != test.spilled {
+				t.Fatalf("unexpected spill file: %q", spillFile)
+			}
+
+			data, err := io.ReadAll(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expecting read %q, got %q", test.expected, data)
+			}
+
+			if spillFile != "" {
+				if err := buf.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := os.Stat(spillFile); !os.IsNotExist(err) {
+					t.Errorf("spill file not removed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputBuffer_SpillRemoved(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("open files cannot be removed on windows")
+	}
+	buf := newOutputBuffer()
+	buf.setPolicy(CaptureSpill, 3)
+	defer buf.Close()
+	buf.Write([]byte("hello world"))
+
+	spillFile := buf.SpillFile()
+	if spillFile == "" {
+		t.Fatal("expecting output spilled to file")
+	}
+	buf.removeSpill()
+	if buf.SpillFile() != "" {
+		t.Errorf("expecting no spill file after removal, got %s", buf.SpillFile())
+	}
+	if _, err := os.Stat(spillFile); !os.IsNotExist(err) {
+		t.Errorf("spill file not removed: %v", err)
+	}
+
+	data, err := io.ReadAll(buf.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world" || buf.String() != "hello world" {
+		t.Errorf("unexpected output after removal: %q", data)
+	}
+	if err := buf.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+}
+
+func TestOutputBuffer_SpillReadWhileWriting(t *testing.T) {
+	buf := newOutputBuffer()
+	buf.setPolicy(CaptureSpill, 3)
+	defer buf.Close()
+
+	var read strings.Builder
+	chunk := make([]byte, 2)
+	for _, data := range []string{"abcd", "efg", "hijkl"} {
+		buf.Write([]byte(data))
+		for {
+			n, err := buf.Read(chunk)
+			read.Write(chunk[:n])
+			if err == io.EOF || n == 0 {
+				break
+			}
+		}
+	}
+	if read.String() != "abcdefghijkl" {
+		t.Errorf("unexpected output read: %q", read.String())
+	}
+}
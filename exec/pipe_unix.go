@@ -22,6 +22,9 @@ func (cb *CommandBuilder) Pipe() *PipedCommandResult {
 	// to ensure data flow between successive processes start
 	for _, p := range cb.procs {
 		result.procs = append(result.procs, p)
+		if cb.procHook != nil {
+			cb.procHook(p)
+		}
 		if err := p.Start().Err(); err != nil {
 			result.errProcs = append(result.errProcs, p)
 			return result
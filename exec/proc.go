@@ -1,8 +1,8 @@
 package exec
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +10,7 @@ import (
 	"os/user"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vladimirvivien/gexe/vars"
@@ -17,18 +18,36 @@ import (
 
 // Proc stores process info when running a process
 type Proc struct {
-	id         int
-	err        error
-	userid     *int
-	groupid    *int
-	state      *os.ProcessState
-	result     *bytes.Buffer
-	outputPipe io.ReadCloser
-	errorPipe  io.ReadCloser
-	inputPipe  io.WriteCloser
-	cmd        *osexec.Cmd
-	process    *os.Process
-	vars       *vars.Variables
+	id          int
+	err         error
+	userid      *int
+	groupid     *int
+	state       *os.ProcessState
+	result      *outputBuffer
+	outputPipe  io.ReadCloser
+	errorPipe   io.ReadCloser
+	inputPipe   io.WriteCloser
+	cmd         *osexec.Cmd
+	process     *os.Process
+	vars        *vars.Variables
+	procGroup   bool
+	allowCodes  []int
+	stats       *ProcStats
+	sampling    time.Duration
+	sampleDone  chan struct{}
+	outClosers  []io.Closer
+	startTime   time.Time
+	endTime     time.Time
+	onStart     []func(*Proc)
+	onDone      []func(*Proc)
+	sandbox     *SandboxOptions
+	groups      []int
+	noSetGroups bool
+	ambientCaps []uintptr
+	expandArgs  []int
+	mu          sync.RWMutex
+	done        chan struct{}
+	doneOnce    sync.Once
 }
 
 // NewProcWithContext sets up command string to be started as an OS process using the specified context.
@@ -37,15 +56,19 @@ type Proc struct {
 func NewProcWithContext(ctx context.Context, cmdStr string) *Proc {
 	words, err := parse(cmdStr)
 	if err != nil {
-		return &Proc{err: err}
+		return newErrProc(err)
+	}
+	if len(words) == 0 {
+		return newErrProc(errors.New("empty command"))
 	}
 
 	command := osexec.CommandContext(ctx, words[0], words[1:]...)
 
 	return &Proc{
 		cmd:    command,
-		result: new(bytes.Buffer),
+		result: newOutputBuffer(),
 		vars:   &vars.Variables{},
+		done:   make(chan struct{}),
 	}
 
 }
@@ -59,18 +82,33 @@ func NewProc(cmdStr string) *Proc {
 
 // NewProcWithVars sets up new command string and session variables for a new proc
 func NewProcWithVars(cmdStr string, variables *vars.Variables) *Proc {
-	p := NewProcWithContext(context.Background(), variables.Eval(cmdStr))
+	p := NewProcWithContext(context.Background(), variables.ExpandCommand(cmdStr))
 	p.vars = variables
 	return p
 }
 
 // NewProcWithContextVars is a convenient function to create new Proc with context and variables.
 func NewProcWithContextVars(ctx context.Context, cmdStr string, variables *vars.Variables) *Proc {
-	proc := NewProcWithContext(ctx, variables.Eval(cmdStr))
+	proc := NewProcWithContext(ctx, variables.ExpandCommand(cmdStr))
 	proc.vars = variables
 	return proc
 }
 
+// NewProcArgs sets up a process, using the specified context, from an explicit command name and
+// argument list. Unlike the command string constructors, name and args are passed to the process
+// as-is: they are not parsed and variables are not expanded, unless selected with Proc.ExpandArgs.
+func NewProcArgs(ctx context.Context, name string, args ...string) *Proc {
+	if name == "" {
+		return newErrProc(errors.New("empty command"))
+	}
+	return &Proc{
+		cmd:    osexec.CommandContext(ctx, name, args...),
+		result: newOutputBuffer(),
+		vars:   &vars.Variables{},
+		done:   make(chan struct{}),
+	}
+}
+
 // StartProcWithContext creates and starts an OS process (with combined stdout/stderr) using the specified context.
 // The function does not wait for the process to complete and must be followed by proc.Wait() to wait for process completion.
 // Then, call proc.Out() or proc.Result() to access the process' result.
@@ -92,14 +130,14 @@ func StartProc(cmdStr string) *Proc {
 
 // StartProcWithVars sets session variables and calls StartProc to create and start a process.
 func StartProcWithVars(cmdStr string, variables *vars.Variables) *Proc {
-	proc := StartProcWithContext(context.Background(), variables.Eval(cmdStr))
+	proc := StartProcWithContext(context.Background(), variables.ExpandCommand(cmdStr))
 	proc.vars = variables
 	return proc
 }
 
 // StartProcWithContextVars is a convenient function that creates and starts a process with a context and variables.
 func StartProcWithContextVars(ctx context.Context, cmdStr string, variables *vars.Variables) *Proc {
-	proc := StartProcWithContext(ctx, variables.Eval(cmdStr))
+	proc := StartProcWithContext(ctx, variables.ExpandCommand(cmdStr))
 	proc.vars = variables
 	return proc
 }
@@ -127,14 +165,14 @@ func RunProc(cmdStr string) *Proc {
 
 // RunProcWithVars sets session variables and calls RunProc
 func RunProcWithVars(cmdStr string, variables *vars.Variables) *Proc {
-	proc := RunProcWithContext(context.Background(), variables.Eval(cmdStr))
+	proc := RunProcWithContext(context.Background(), variables.ExpandCommand(cmdStr))
 	proc.vars = variables
 	return proc
 }
 
 // RunProcWithContextVars runs a process with a context and session variables
 func RunProcWithContextVars(ctx context.Context, cmdStr string, variables *vars.Variables) *Proc {
-	proc := RunProcWithContext(ctx, variables.Eval(cmdStr))
+	proc := RunProcWithContext(ctx, variables.ExpandCommand(cmdStr))
 	proc.vars = variables
 	return proc
 }
@@ -167,6 +205,7 @@ func RunWithContextVars(ctx context.Context, cmdStr string, variables *vars.Vari
 // to the process' input/output (stdin,stdout,stderr) prior to calling Proc.Start().
 func (p *Proc) Start() *Proc {
 	if p.err != nil {
+		p.markDone()
 		return p
 	}
 
@@ -176,6 +215,7 @@ func (p *Proc) Start() *Proc {
 
 	if p.cmd == nil {
 		p.err = fmt.Errorf("cmd is nill")
+		p.markDone()
 		return p
 	}
 
@@ -187,18 +227,41 @@ func (p *Proc) Start() *Proc {
 		p.cmd.Stderr = p.result
 	}
 
+	// expand the arguments selected with ExpandArgs
+	for _, i := range p.expandArgs {
+		if p.vars != nil {
+			p.cmd.Args[i] = p.vars.Eval(p.cmd.Args[i])
+		}
+	}
+	p.expandArgs = nil
+
 	// apply user id and user grp
-	p.applyCredentials()
+	if err := p.applyCredentials(); err != nil {
+		p.err = err
+		p.markDone()
+		return p
+	}
+	p.applyProcGroup()
 
 	if err := p.cmd.Start(); err != nil {
-		p.err = err
+		p.err = p.startError(err)
+		p.markDone()
 		return p
 	}
 
+	p.mu.Lock()
 	p.process = p.cmd.Process
+	p.startTime = time.Now()
+	callbacks := p.onStart
+	p.onStart = nil
+	p.mu.Unlock()
 	p.id = p.cmd.Process.Pid
+	p.startSampler()
 	p.state = p.cmd.ProcessState
 
+	for _, fn := range callbacks {
+		fn(p)
+	}
 	return p
 }
 
@@ -208,6 +271,28 @@ func (p *Proc) SetVars(variables *vars.Variables) *Proc {
 	return p
 }
 
+// ExpandArgs selects arguments, by their index in the argument list (not counting the
+// command name), to be expanded with the variables of the process (see SetVars) when it
+// starts. Other arguments are left as-is, which makes it safe to mix untrusted values
+// with expanded ones:
+//
+//	NewProcArgs(ctx, "grep", "-n", userPattern, "$HOME/notes.txt").ExpandArgs(2)
+//
+// An index outside of the argument list sets the error of the process.
+func (p *Proc) ExpandArgs(indices ...int) *Proc {
+	if p.err != nil {
+		return p
+	}
+	for _, i := range indices {
+		if i < 0 || i >= len(p.cmd.Args)-1 {
+			p.err = fmt.Errorf("expand args: index %d out of range", i)
+			return p
+		}
+		p.expandArgs = append(p.expandArgs, i+1)
+	}
+	return p
+}
+
 // Command returns the os/exec.Cmd that started the process
 func (p *Proc) Command() *osexec.Cmd {
 	return p.cmd
@@ -254,6 +339,13 @@ func (p *Proc) SetWorkDir(path string) *Proc {
 	return p
 }
 
+// SetProcGroup starts the process in its own process group (or console group on Windows)
+// so that signals sent with Proc.Signal are delivered to the process and all of its children.
+func (p *Proc) SetProcGroup() *Proc {
+	p.procGroup = true
+	return p
+}
+
 // Peek attempts to read process state information
 func (p *Proc) Peek() *Proc {
 	p.state = p.cmd.ProcessState
@@ -264,28 +356,45 @@ func (p *Proc) Peek() *Proc {
 // Wait should follow Proc.StartXXX() methods to ensure completion.
 func (p *Proc) Wait() *Proc {
 	if p.err != nil {
+		p.markDone()
 		return p
 	}
 
-	if !p.hasStarted() {
-		p.err = fmt.Errorf("process not started")
+	if p.cmd == nil {
+		p.err = fmt.Errorf("command is nill")
+		p.markDone()
 		return p
 	}
 
-	if p.cmd == nil {
-		p.err = fmt.Errorf("command is nill")
+	if !p.hasStarted() {
+		p.err = fmt.Errorf("process not started")
+		p.markDone()
 		return p
 	}
-	if err := p.cmd.Wait(); err != nil {
+
+	if err := p.cmd.Wait(); err != nil && !p.isAllowedExit(err) {
 		p.err = err
 		// use return below to get proc info
 	}
+	p.mu.Lock()
+	p.endTime = time.Now()
+	p.state = p.cmd.ProcessState
+	p.mu.Unlock()
+	// flush output writers once all output has been copied
+	for _, closer := range p.outClosers {
+		closer.Close()
+	}
+	p.markDone()
+	if p.sampleDone != nil {
+		<-p.sampleDone
+	}
 	return p.Peek()
 }
 
 // Run starts and waits for a process to complete.
 func (p *Proc) Run() *Proc {
 	if p.err != nil {
+		p.markDone()
 		return p
 	}
 
@@ -317,12 +426,21 @@ func (p *Proc) ExitCode() int {
 	return p.state.ExitCode()
 }
 
-// IsSuccess returns true if proc exit ok
+// IsSuccess returns true if proc exit ok or with
+// an exit code accepted by Proc.AllowExitCodes.
 func (p *Proc) IsSuccess() bool {
 	if p.state == nil {
 		return false
 	}
-	return p.state.Success()
+	return p.state.Success() || p.isAllowedCode(p.state.ExitCode())
+}
+
+// AllowExitCodes sets non-zero exit codes that are accepted as a successful completion
+// (i.e. 1 for grep with no match or diff with differences). Accepted exit codes
+// do not set Proc.Err and the actual code remains available from Proc.ExitCode.
+func (p *Proc) AllowExitCodes(codes ...int) *Proc {
+	p.allowCodes = append(p.allowCodes, codes...)
+	return p
 }
 
 // SysTime returns proc system cpu time
@@ -341,11 +459,97 @@ func (p *Proc) UserTime() time.Duration {
 	return p.state.UserTime()
 }
 
+// IsRunning returns true if the process has been started and has not yet been waited on
+func (p *Proc) IsRunning() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.process == nil {
+		return false
+	}
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done returns a channel that is closed once the process has been waited on
+// or has failed to start.
+func (p *Proc) Done() <-chan struct{} {
+	return p.done
+}
+
 // Err returns any execution error
 func (p *Proc) Err() error {
 	return p.err
 }
 
+// OnStart registers fn to be called when the process is started. If the process
+// has already started, fn is called immediately.
+func (p *Proc) OnStart(fn func(*Proc)) *Proc {
+	p.mu.Lock()
+	if p.process != nil {
+		p.mu.Unlock()
+		fn(p)
+		return p
+	}
+	p.onStart = append(p.onStart, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// OnDone registers fn to be called when the process completes: after Wait returns, or when the
+// process fails to start. If the process has already completed, fn is called immediately.
+func (p *Proc) OnDone(fn func(*Proc)) *Proc {
+	p.mu.Lock()
+	select {
+	case <-p.done:
+		p.mu.Unlock()
+		fn(p)
+		return p
+	default:
+	}
+	p.onDone = append(p.onDone, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// StartTime returns the time when the process was started, or the zero time if it was not started
+func (p *Proc) StartTime() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.startTime
+}
+
+// EndTime returns the time when Wait observed the completion of the process, or the zero time
+func (p *Proc) EndTime() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.endTime
+}
+
+// OutputSize returns the number of bytes written to the captured output of the process,
+// including bytes dropped by the capture policy (see Proc.WithCapture). Output written
+// to streams set with Proc.SetStdout or Proc.SetStderr is not counted.
+func (p *Proc) OutputSize() int64 {
+	return p.result.Written()
+}
+
+// Duration returns how long the process ran: from Start to the completion of Wait, or
+// until now if the process is still running. It returns 0 if the process was not started.
+func (p *Proc) Duration() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	switch {
+	case p.startTime.IsZero():
+		return 0
+	case p.endTime.IsZero():
+		return time.Since(p.startTime)
+	}
+	return p.endTime.Sub(p.startTime)
+}
+
 // Kill halts the process
 func (p *Proc) Kill() *Proc {
 	if p.err != nil {
@@ -364,12 +568,93 @@ func (p *Proc) Kill() *Proc {
 //
 // NB: Out used to start/wait the process if necessary. However, that behavior has been deprecated.
 // You must ensure the process has been properly initiated and wait for completion prior to calling Out.
+//
+// If output was dropped by the capture policy (see Proc.WithCapture), the reader includes a
+// truncation notice where the output was cut.
 func (p *Proc) Out() io.Reader {
+	if p.result == nil {
+		return nil
+	}
+	if dropped := p.result.Dropped(); dropped > 0 {
+		notice := strings.NewReader(truncationNotice(dropped) + "\n")
+		if p.result.Policy() == CaptureTail {
+			return io.MultiReader(notice, p.result)
+		}
+		return io.MultiReader(p.result, notice)
+	}
 	return p.result
 }
 
+// WithCapture sets the policy used to retain the combined output of the process, where limit
+// is the number of bytes kept in memory: CaptureHead keeps the first limit bytes, CaptureTail keeps
+// the last limit bytes, and CaptureSpill writes output beyond limit to a temporary file (see Proc.Close).
+// A limit <= 0 captures all output. It must be called before the process is started.
+func (p *Proc) WithCapture(policy CapturePolicy, limit int) *Proc {
+	if p.result != nil {
+		p.result.setPolicy(policy, limit)
+	}
+	return p
+}
+
+// Dropped returns the number of output bytes discarded by the capture policy
+func (p *Proc) Dropped() int64 {
+	if p.result == nil {
+		return 0
+	}
+	return p.result.Dropped()
+}
+
+// Truncated returns true if any output was discarded by the capture policy
+func (p *Proc) Truncated() bool {
+	return p.Dropped() > 0
+}
+
+// SpillFile returns the path of the temporary file holding output beyond the
+// in-memory limit when CaptureSpill is used, or an empty string otherwise.
+// The file is removed from disk when the process is done; its content remains
+// readable, through the Proc, until Proc.Close. On Windows, where an open file
+// cannot be removed, the file is removed by Proc.Close.
+func (p *Proc) SpillFile() string {
+	if p.result == nil {
+		return ""
+	}
+	return p.result.SpillFile()
+}
+
+// Close releases resources held for the captured output, such as the
+// temporary file used by CaptureSpill. The output is not accessible afterward.
+// Close should be called once the output of a process using CaptureSpill is no longer needed.
+func (p *Proc) Close() error {
+	if p.result == nil {
+		return nil
+	}
+	return p.result.Close()
+}
+
+// Output returns the combined stdout and stderr captured so far. Unlike Proc.Out,
+// it does not consume the output and it is safe to call while the process is running.
+// With CaptureSpill, the spilled output is read from disk, in full, on every call; use
+// Proc.OutputReader to avoid loading it in memory.
+func (p *Proc) Output() string {
+	if p.result == nil {
+		return ""
+	}
+	return p.result.String()
+}
+
+// OutputReader returns a reader of the combined stdout and stderr captured so far. Like
+// Proc.Output, it does not consume the output; with CaptureSpill, the spilled output is
+// streamed from disk instead of being loaded in memory.
+func (p *Proc) OutputReader() io.Reader {
+	if p.result == nil {
+		return strings.NewReader("")
+	}
+	return p.result.Reader()
+}
+
 // Result returns the combined stdout and stderr (see Proc.Out()) result as a string value.
 // If there was a previous error in the call chain, this will return the error as a string.
+// With CaptureSpill, the spilled output is read from disk, in full, on every call.
 func (p *Proc) Result() string {
 	if p.result == nil {
 		return "result <nil>"
@@ -378,6 +663,12 @@ func (p *Proc) Result() string {
 	if err := p.Err(); err != nil && result == "" {
 		return err.Error()
 	}
+	if dropped := p.result.Dropped(); dropped > 0 {
+		if p.result.Policy() == CaptureTail {
+			return truncationNotice(dropped) + "\n" + result
+		}
+		return result + "\n" + truncationNotice(dropped)
+	}
 	return result
 }
 
@@ -429,11 +720,60 @@ func (p *Proc) GetErrorPipe() io.Reader {
 	return p.errorPipe
 }
 
+// isAllowedExit returns true if err is an exit error with an accepted exit code
+func (p *Proc) isAllowedExit(err error) bool {
+	var exitErr *osexec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	return p.isAllowedCode(exitErr.ExitCode())
+}
+
+func (p *Proc) isAllowedCode(code int) bool {
+	for _, allowed := range p.allowCodes {
+		if code == allowed && code >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// newErrProc returns a Proc, that cannot be started, for a command that failed to set up
+func newErrProc(err error) *Proc {
+	proc := &Proc{
+		cmd:    &osexec.Cmd{},
+		result: newOutputBuffer(),
+		vars:   &vars.Variables{},
+		err:    err,
+		done:   make(chan struct{}),
+	}
+	proc.markDone()
+	return proc
+}
+
+func (p *Proc) markDone() {
+	p.doneOnce.Do(func() {
+		if p.result != nil {
+			p.result.removeSpill()
+		}
+		p.mu.Lock()
+		close(p.done)
+		callbacks := p.onDone
+		p.onDone = nil
+		p.mu.Unlock()
+		for _, fn := range callbacks {
+			fn(p)
+		}
+	})
+}
+
 func (p *Proc) hasStarted() bool {
 	return (p.cmd.Process != nil && p.cmd.Process.Pid != 0)
 }
 
-// Parse parses the command string and returns its tokens
+// Parse parses the command string, using POSIX shell quoting rules, and returns its tokens.
+// On Windows, a backslash outside quotes is a literal character rather than an escape.
+// Errors are reported as *ParseError with the column where tokenizing failed.
 func Parse(cmd string) ([]string, error) {
 	return parse(cmd)
 }
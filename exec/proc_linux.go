@@ -0,0 +1,99 @@
+//go:build linux
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const sandboxSupported = true
+
+// applySandbox configures the namespaces, ID mappings, and root directory of a sandboxed process
+func (p *Proc) applySandbox() {
+	if p.sandbox == nil {
+		return
+	}
+	opts := p.sandbox
+	if p.cmd.SysProcAttr == nil {
+		p.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	attr := p.cmd.SysProcAttr
+
+	for _, ns := range []struct {
+		enabled bool
+		flag    uintptr
+	}{
+		{opts.User, syscall.CLONE_NEWUSER},
+		{opts.Mount, syscall.CLONE_NEWNS},
+		{opts.PID, syscall.CLONE_NEWPID},
+		{opts.UTS, syscall.CLONE_NEWUTS},
+		{opts.Network || opts.NoNetwork, syscall.CLONE_NEWNET},
+	} {
+		if ns.enabled {
+			attr.Cloneflags |= ns.flag
+		}
+	}
+
+	if opts.User {
+		attr.UidMappings = idMappings(opts.UIDMappings, os.Getuid())
+		attr.GidMappings = idMappings(opts.GIDMappings, os.Getgid())
+	}
+	if opts.Chroot != "" {
+		attr.Chroot = opts.Chroot
+		if p.cmd.Dir == "" {
+			p.cmd.Dir = "/"
+		}
+	}
+}
+
+// sandboxIDs returns the IDs, inside the user namespace of the sandbox, that map to the
+// host IDs uid and gid. It returns an error if an ID is not mapped in the namespace.
+func (p *Proc) sandboxIDs(uid, gid int) (int, int, error) {
+	if p.sandbox == nil || !p.sandbox.User {
+		return uid, gid, nil
+	}
+	nsUID, ok := containerID(idMappings(p.sandbox.UIDMappings, os.Getuid()), uid)
+	if !ok {
+		return 0, 0, fmt.Errorf("sandbox: uid %d is not mapped in the user namespace, set the user with Proc.SetUserid", uid)
+	}
+	nsGID, ok := containerID(idMappings(p.sandbox.GIDMappings, os.Getgid()), gid)
+	if !ok {
+		return 0, 0, fmt.Errorf("sandbox: gid %d is not mapped in the user namespace, set the group with Proc.SetGroupid", gid)
+	}
+	return nsUID, nsGID, nil
+}
+
+// containerID returns the ID that maps to hostID in mappings
+func containerID(mappings []syscall.SysProcIDMap, hostID int) (int, bool) {
+	for _, m := range mappings {
+		if hostID >= m.HostID && hostID < m.HostID+m.Size {
+			return m.ContainerID + hostID - m.HostID, true
+		}
+	}
+	return 0, false
+}
+
+// idMappings converts mappings, or maps hostID to root when there are none
+func idMappings(mappings []IDMap, hostID int) []syscall.SysProcIDMap {
+	if len(mappings) == 0 {
+		return []syscall.SysProcIDMap{{ContainerID: 0, HostID: hostID, Size: 1}}
+	}
+	result := make([]syscall.SysProcIDMap, len(mappings))
+	for i, m := range mappings {
+		result[i] = syscall.SysProcIDMap{ContainerID: m.ContainerID, HostID: m.HostID, Size: m.Size}
+	}
+	return result
+}
+
+// applyAmbientCaps keeps the requested capabilities as ambient capabilities of the process
+func (p *Proc) applyAmbientCaps() {
+	if len(p.ambientCaps) == 0 {
+		return
+	}
+	if p.cmd.SysProcAttr == nil {
+		p.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	p.cmd.SysProcAttr.AmbientCaps = append(p.cmd.SysProcAttr.AmbientCaps, p.ambientCaps...)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package exec
+
+const sandboxSupported = false
+
+// applySandbox is a no-op since Linux namespaces are not available
+func (p *Proc) applySandbox() {}
+
+// sandboxIDs returns uid and gid since there is no user namespace to map them to
+func (p *Proc) sandboxIDs(uid, gid int) (int, int, error) {
+	return uid, gid, nil
+}
+
+// applyAmbientCaps is a no-op since ambient capabilities are Linux specific
+func (p *Proc) applyAmbientCaps() {}
@@ -3,28 +3,122 @@
 package exec
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
 	"syscall"
 )
 
-// applyCredentials applies the user and group IDs to the command.
-func (p *Proc) applyCredentials() {
-	// apply user id and user grp
-	var procCred *syscall.Credential
+// applyCredentials applies the user and group IDs, supplementary groups, ambient
+// capabilities, and sandbox options to the command.
+func (p *Proc) applyCredentials() error {
+	p.applySandbox()
+	p.applyAmbientCaps()
+
+	// apply user id, user grp, and supplementary groups. IDs that are
+	// not set default to the IDs of the running program, as seen from
+	// the user namespace of the sandbox, if any.
+	if p.userid == nil && p.groupid == nil && len(p.groups) == 0 {
+		return nil
+	}
+	uid, gid, err := p.sandboxIDs(os.Getuid(), os.Getgid())
+	if err != nil {
+		return err
+	}
+	procCred := &syscall.Credential{
+		Uid:         uint32(uid),
+		Gid:         uint32(gid),
+		NoSetGroups: p.noSetGroups,
+	}
 	if p.userid != nil {
-		procCred = &syscall.Credential{
-			Uid: uint32(*p.userid),
-		}
+		procCred.Uid = uint32(*p.userid)
 	}
 	if p.groupid != nil {
-		if procCred == nil {
-			procCred = new(syscall.Credential)
-		}
 		procCred.Gid = uint32(*p.groupid)
 	}
-	if procCred != nil {
-		if p.cmd.SysProcAttr == nil {
-			p.cmd.SysProcAttr = new(syscall.SysProcAttr)
-		}
-		p.cmd.SysProcAttr.Credential = procCred
+	for _, gid := range p.groups {
+		procCred.Groups = append(procCred.Groups, uint32(gid))
+	}
+	if p.cmd.SysProcAttr == nil {
+		p.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	p.cmd.SysProcAttr.Credential = procCred
+	return nil
+}
+
+// startError explains a permission error returned when starting a process that
+// requested privileged options (i.e. credentials, capabilities, or sandbox namespaces).
+func (p *Proc) startError(err error) error {
+	if !errors.Is(err, syscall.EPERM) {
+		return err
+	}
+	var requested []string
+	if p.userid != nil || p.groupid != nil {
+		requested = append(requested, "user or group credentials")
+	}
+	if len(p.groups) > 0 && !p.noSetGroups {
+		requested = append(requested, "supplementary groups")
+	}
+	if len(p.ambientCaps) > 0 {
+		requested = append(requested, "ambient capabilities")
+	}
+	if p.sandbox != nil {
+		requested = append(requested, "sandbox namespaces")
+	}
+	if len(requested) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: the caller lacks the privileges required for %s", err, strings.Join(requested, " and "))
+}
+
+// applyProcGroup places the process in its own process group when requested.
+func (p *Proc) applyProcGroup() {
+	if !p.procGroup {
+		return
+	}
+	if p.cmd.SysProcAttr == nil {
+		p.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	p.cmd.SysProcAttr.Setpgid = true
+}
+
+// Signal sends sig to the running process. If the process was started with
+// Proc.SetProcGroup, the signal is delivered to its entire process group.
+// Unlike Proc.Kill, a delivery error is returned and not stored in Proc.Err.
+func (p *Proc) Signal(sig os.Signal) error {
+	p.mu.RLock()
+	process := p.process
+	p.mu.RUnlock()
+	if process == nil {
+		return fmt.Errorf("process not started")
+	}
+
+	if !p.procGroup {
+		return process.Signal(sig)
+	}
+
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal: %s", sig)
+	}
+	return syscall.Kill(-process.Pid, sysSig)
+}
+
+// MaxRSS returns the maximum resident set size, in bytes, used by the exited process
+// or -1 if the process has not exited.
+func (p *Proc) MaxRSS() int64 {
+	if p.state == nil {
+		return -1
+	}
+	usage, ok := p.state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return -1
+	}
+	// ru_maxrss is reported in bytes on darwin and kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss)
 	}
+	return int64(usage.Maxrss) * 1024
 }
@@ -4,9 +4,12 @@ package exec
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/vladimirvivien/gexe/vars"
@@ -645,7 +648,7 @@ func TestRun(t *testing.T) {
 		},
 		{
 			name:   "bad command",
-			cmdStr: `date -xx"`,
+			cmdStr: `date -xx`,
 			exec: func(cmd string) {
 				result := Run(cmd)
 				if !strings.Contains(result, "illegal") && !strings.Contains(result, "invalid") {
@@ -655,6 +658,16 @@ func TestRun(t *testing.T) {
 				t.Log(result)
 			},
 		},
+		{
+			name:   "unterminated quote",
+			cmdStr: `date -xx"`,
+			exec: func(cmd string) {
+				result := Run(cmd)
+				if !strings.Contains(result, "unterminated double quote") {
+					t.Errorf("Expecting 'unterminated double quote', got: %s", result)
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -663,3 +676,183 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestProcSignal(t *testing.T) {
+	tests := []struct {
+		name      string
+		procGroup bool
+	}{
+		{name: "signal process"},
+		{name: "signal process group", procGroup: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc(`/bin/sh -c "sleep 10"`)
+			if test.procGroup {
+				p.SetProcGroup()
+			}
+			if err := p.Signal(syscall.SIGTERM); err == nil {
+				t.Fatal("expecting error when signaling unstarted process")
+			}
+			if err := p.Start().Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !p.IsRunning() {
+				t.Fatal("expecting process to be running")
+			}
+			if err := p.Signal(syscall.SIGTERM); err != nil {
+				t.Fatal(err)
+			}
+			p.Wait()
+			if p.IsRunning() {
+				t.Error("process should not be running after wait")
+			}
+			if p.IsSuccess() {
+				t.Error("expecting process to be terminated by signal")
+			}
+			select {
+			case <-p.Done():
+			default:
+				t.Error("done channel should be closed after wait")
+			}
+		})
+	}
+}
+
+func TestProcAllowExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdStr   string
+		allowed  []int
+		exitCode int
+		success  bool
+	}{
+		{name: "zero exit", cmdStr: `/bin/sh -c "exit 0"`, exitCode: 0, success: true},
+		{name: "non-zero not allowed", cmdStr: `/bin/sh -c "exit 1"`, exitCode: 1},
+		{name: "non-zero allowed", cmdStr: `/bin/sh -c "exit 1"`, allowed: []int{0, 1}, exitCode: 1, success: true},
+		{name: "other code not allowed", cmdStr: `/bin/sh -c "exit 2"`, allowed: []int{0, 1}, exitCode: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc(test.cmdStr).AllowExitCodes(test.allowed...).Run()
+			if (p.Err() == nil) != test.success {
+				t.Errorf("unexpected error state: %v", p.Err())
+			}
+			if p.IsSuccess() != test.success {
+				t.Errorf("expecting IsSuccess %t", test.success)
+			}
+			if p.ExitCode() != test.exitCode {
+				t.Errorf("expecting exit code %d, got %d", test.exitCode, p.ExitCode())
+			}
+		})
+	}
+}
+
+func TestNewProcArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      string
+		args     []string
+		expected string
+		errored  bool
+	}{
+		{name: "no args", cmd: "echo", expected: ""},
+		{name: "args with spaces and quotes", cmd: "echo", args: []string{`"hello"`, "big world"}, expected: `"hello" big world`},
+		{name: "args not expanded", cmd: "echo", args: []string{"$HOME", "${USER}"}, expected: "$HOME ${USER}"},
+		{name: "no argument injection", cmd: "ls", args: []string{"-d", "/ ; echo injected"}, errored: true},
+		{name: "empty command", cmd: "", errored: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProcArgs(context.Background(), test.cmd, test.args...).Run()
+			if test.errored {
+				if p.Err() == nil {
+					t.Fatalf("expecting error, got result: %s", p.Result())
+				}
+				if strings.Contains(p.Result(), "injected\n") {
+					t.Errorf("unexpected command injection: %s", p.Result())
+				}
+				return
+			}
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if p.Result() != test.expected {
+				t.Errorf("expecting %q, got %q", test.expected, p.Result())
+			}
+		})
+	}
+}
+
+func TestProcExpandArgs(t *testing.T) {
+	variables := vars.New().SetVar("NAME", "world")
+	p := NewProcArgs(context.Background(), "printf", "[%s]", "hello ${NAME}", "$NAME", "'$NAME'").SetVars(variables).ExpandArgs(1, 3)
+	if p.Command().Args[2] != "hello ${NAME}" {
+		t.Errorf("expecting arg to be expanded when started, got %q", p.Command().Args[2])
+	}
+	if err := p.Run().Err(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Result() != "[hello world][$NAME]['world']" {
+		t.Errorf("unexpected result: %s", p.Result())
+	}
+
+	if p := NewProcArgs(context.Background(), "echo", "$NAME").ExpandArgs(1); p.Err() == nil {
+		t.Error("expecting error for out of range index")
+	}
+	if p := NewProcArgs(context.Background(), "echo", "$NAME").ExpandArgs(-1); p.Err() == nil {
+		t.Error("expecting error for negative index")
+	}
+}
+
+func TestProcWithCapture(t *testing.T) {
+	cmdStr := `/bin/sh -c "for i in 1 2 3 4 5 6 7 8 9; do echo line-$i; done"`
+	tests := []struct {
+		name     string
+		policy   CapturePolicy
+		limit    int
+		expected string
+		dropped  int64
+	}{
+		{name: "head", policy: CaptureHead, limit: 14, expected: "line-1\nline-2\n" + truncationNotice(49)},
+		{name: "tail", policy: CaptureTail, limit: 14, expected: truncationNotice(49) + "\nline-8\nline-9"},
+		{name: "spill", policy: CaptureSpill, limit: 14, expected: "line-1\nline-2\nline-3\nline-4\nline-5\nline-6\nline-7\nline-8\nline-9"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc(cmdStr).WithCapture(test.policy, test.limit)
+			defer p.Close()
+			if err := p.Run().Err(); err != nil {
+				t.Fatal(err)
+			}
+			if p.Result() != test.expected {
+				t.Errorf("expecting result %q, got %q", test.expected, p.Result())
+			}
+			if test.policy == CaptureSpill && p.SpillFile() != "" {
+				t.Errorf("expecting spill file removed when done, got %s", p.SpillFile())
+			}
+			output, err := io.ReadAll(p.OutputReader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != p.Output() {
+				t.Errorf("expecting output reader %q, got %q", p.Output(), output)
+			}
+			if test.policy != CaptureSpill && !p.Truncated() {
+				t.Error("expecting truncated output")
+			}
+
+			out, err := io.ReadAll(p.Out())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(out)) != test.expected {
+				t.Errorf("expecting output %q, got %q", test.expected, out)
+			}
+		})
+	}
+}
@@ -2,8 +2,49 @@
 
 package exec
 
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
 // applyCredentials is a no-op as this works vastly different on Windows.
-func (p *Proc) applyCredentials() {
+func (p *Proc) applyCredentials() error {
 	// Windows doesn't support user/group IDs in the same way {Li|U}nix does.
 	// Windows impersonation will not be supported in this package a this time.
+	return nil
+}
+
+// startError returns err as is since Windows does not support privileged options
+func (p *Proc) startError(err error) error {
+	return err
+}
+
+// applyProcGroup starts the process in a new console process group when requested.
+func (p *Proc) applyProcGroup() {
+	if !p.procGroup {
+		return
+	}
+	if p.cmd.SysProcAttr == nil {
+		p.cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	p.cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// Signal sends sig to the running process. On Windows, only os.Kill
+// is supported by the runtime. Unlike Proc.Kill, a delivery error is
+// returned and not stored in Proc.Err.
+func (p *Proc) Signal(sig os.Signal) error {
+	p.mu.RLock()
+	process := p.process
+	p.mu.RUnlock()
+	if process == nil {
+		return fmt.Errorf("process not started")
+	}
+	return process.Signal(sig)
+}
+
+// MaxRSS is not supported on Windows and always returns -1
+func (p *Proc) MaxRSS() int64 {
+	return -1
 }
@@ -0,0 +1,116 @@
+package exec
+
+import (
+	"time"
+
+	"github.com/vladimirvivien/gexe/procs"
+)
+
+// ProcSample is a resource usage measurement of a running process
+type ProcSample struct {
+	Time       time.Time
+	CPUPercent float64
+	RSS        int64
+	Threads    int
+	FDs        int
+}
+
+// ProcStats stores the resource usage samples of a process and their summary
+type ProcStats struct {
+	Samples        []ProcSample
+	PeakCPUPercent float64
+	AvgCPUPercent  float64
+	PeakRSS        int64
+	AvgRSS         int64
+	PeakThreads    int
+	PeakFDs        int
+}
+
+// WithSampling enables resource usage sampling, at the specified interval, while the process
+// is running. Samples are read from /proc (where available) and the summary is available from
+// Proc.Stats after Proc.Wait. It must be called before the process is started.
+func (p *Proc) WithSampling(interval time.Duration) *Proc {
+	p.sampling = interval
+	return p
+}
+
+// Stats returns the resource usage collected while the process was running.
+// It returns nil if sampling was not enabled (see Proc.WithSampling) or if
+// the process has not been waited on.
+func (p *Proc) Stats() *ProcStats {
+	if p.sampleDone == nil {
+		return nil
+	}
+	select {
+	case <-p.sampleDone:
+		return p.stats
+	default:
+		return nil
+	}
+}
+
+// startSampler samples the process at the configured interval until it is done
+func (p *Proc) startSampler() {
+	if p.sampling <= 0 {
+		return
+	}
+	p.stats = new(ProcStats)
+	p.sampleDone = make(chan struct{})
+
+	go func(pid int) {
+		defer close(p.sampleDone)
+		ticker := time.NewTicker(p.sampling)
+		defer ticker.Stop()
+
+		var lastCPU time.Duration
+		var lastTime time.Time
+		for {
+			info, err := procs.Get(pid)
+			if err == nil && info.State != "Z" {
+				now := time.Now()
+				sample := ProcSample{Time: now, RSS: info.RSS, Threads: info.Threads}
+				if !lastTime.IsZero() {
+					sample.CPUPercent = float64(info.CPUTime-lastCPU) / float64(now.Sub(lastTime)) * 100
+				}
+				sample.FDs, _ = procs.OpenFDs(pid)
+				lastCPU, lastTime = info.CPUTime, now
+				p.stats.add(sample)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				p.stats.summarize()
+				return
+			}
+		}
+	}(p.cmd.Process.Pid)
+}
+
+func (s *ProcStats) add(sample ProcSample) {
+	s.Samples = append(s.Samples, sample)
+}
+
+// summarize computes peak and average values from the samples
+func (s *ProcStats) summarize() {
+	if len(s.Samples) == 0 {
+		return
+	}
+	var totalCPU float64
+	var totalRSS int64
+	for i, sample := range s.Samples {
+		// the first sample has no CPU delta and is excluded from the CPU average
+		if i > 0 {
+			totalCPU += sample.CPUPercent
+		}
+		totalRSS += sample.RSS
+		s.PeakCPUPercent = max(s.PeakCPUPercent, sample.CPUPercent)
+		s.PeakRSS = max(s.PeakRSS, sample.RSS)
+		s.PeakThreads = max(s.PeakThreads, sample.Threads)
+		s.PeakFDs = max(s.PeakFDs, sample.FDs)
+	}
+	if len(s.Samples) > 1 {
+		s.AvgCPUPercent = totalCPU / float64(len(s.Samples)-1)
+	}
+	s.AvgRSS = totalRSS / int64(len(s.Samples))
+}
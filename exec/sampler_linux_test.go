@@ -0,0 +1,56 @@
+//go:build linux
+
+package exec
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdStr   string
+		interval time.Duration
+		sampled  bool
+	}{
+		{name: "sampling disabled", cmdStr: "sleep 0.2"},
+		{name: "idle process", cmdStr: "sleep 0.5", interval: 50 * time.Millisecond, sampled: true},
+		{name: "busy process", cmdStr: `/bin/sh -c "i=0; while [ $i -lt 200000 ]; do i=$((i+1)); done"`, interval: 50 * time.Millisecond, sampled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc(test.cmdStr).WithSampling(test.interval)
+			if err := p.Start().Err(); err != nil {
+				t.Fatal(err)
+			}
+			if p.Stats() != nil {
+				t.Error("expecting no stats before wait")
+			}
+			if err := p.Wait().Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			stats := p.Stats()
+			if !test.sampled {
+				if stats != nil {
+					t.Error("expecting no stats when sampling is disabled")
+				}
+				return
+			}
+			if stats == nil || len(stats.Samples) < 2 {
+				t.Fatalf("expecting multiple samples, got %+v", stats)
+			}
+			if stats.PeakRSS <= 0 || stats.AvgRSS <= 0 || stats.AvgRSS > stats.PeakRSS {
+				t.Errorf("unexpected RSS summary: peak %d, avg %d", stats.PeakRSS, stats.AvgRSS)
+			}
+			if stats.PeakThreads < 1 || stats.PeakFDs < 1 {
+				t.Errorf("unexpected threads %d or fds %d", stats.PeakThreads, stats.PeakFDs)
+			}
+			if stats.AvgCPUPercent > stats.PeakCPUPercent {
+				t.Errorf("average CPU %f above peak %f", stats.AvgCPUPercent, stats.PeakCPUPercent)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// IDMap maps a range of user or group IDs inside a user namespace to IDs on the host
+type IDMap struct {
+	// ContainerID is the first ID inside the namespace
+	ContainerID int
+	// HostID is the first ID on the host
+	HostID int
+	// Size is the number of mapped IDs
+	Size int
+}
+
+// SandboxOptions configures the Linux namespaces in which a process runs (see Proc.WithSandbox)
+type SandboxOptions struct {
+	// User runs the process in a new user namespace. Unless mappings are provided,
+	// the current user and group are mapped to root (ID 0) inside the namespace,
+	// which allows the other namespaces to be created without privileges.
+	User bool
+	// Mount runs the process in a new mount namespace
+	Mount bool
+	// PID runs the process in a new PID namespace, where it has PID 1. Note that
+	// /proc still shows the host processes unless it is remounted in a mount namespace.
+	PID bool
+	// UTS runs the process in a new UTS namespace (hostname and domain name)
+	UTS bool
+	// Network runs the process in a new network namespace, private to the process, that
+	// only has a loopback interface (down). It can be configured from the host while the
+	// process runs, using its PID (i.e. ip link set veth1 netns <pid>).
+	Network bool
+	// NoNetwork runs the process in a new, empty, network namespace without network access.
+	// Unlike Network, the namespace is not meant to be configured.
+	NoNetwork bool
+	// UIDMappings and GIDMappings map IDs inside the user namespace to IDs on the host
+	UIDMappings []IDMap
+	GIDMappings []IDMap
+	// Chroot changes the root directory of the process to the specified directory,
+	// which must contain the command and its dependencies
+	Chroot string
+	// PivotRoot is not supported: pivot_root(2) must be called by the process after it is
+	// created and before the command is executed, which os/exec does not allow. When set,
+	// Proc.Err returns an error; use Chroot, with Mount, instead.
+	PivotRoot string
+}
+
+// WithSandbox runs the process in the Linux namespaces configured by opts, without a container runtime:
+//
+//	exec.NewProc("./build.sh").WithSandbox(exec.SandboxOptions{User: true, Mount: true, PID: true, NoNetwork: true}).Run()
+//
+// Creating namespaces other than the user namespace requires privileges, or opts.User. With opts.User,
+// the IDs set with Proc.SetUserid and Proc.SetGroupid are IDs inside the namespace, and IDs that are not
+// set default to the IDs that the running program is mapped to. On platforms other than Linux, Proc.Err
+// returns an error.
+func (p *Proc) WithSandbox(opts SandboxOptions) *Proc {
+	if p.err != nil {
+		return p
+	}
+	if !sandboxSupported {
+		p.err = fmt.Errorf("sandbox: not supported on %s", runtime.GOOS)
+		return p
+	}
+	if opts.PivotRoot != "" {
+		p.err = errors.New("sandbox: pivot_root is not supported, use Chroot")
+		return p
+	}
+	if !opts.User && (len(opts.UIDMappings) > 0 || len(opts.GIDMappings) > 0) {
+		p.err = errors.New("sandbox: ID mappings require a user namespace")
+		return p
+	}
+	if opts.Chroot != "" {
+		opts.Chroot = p.vars.Eval(opts.Chroot)
+		info, err := os.Stat(opts.Chroot)
+		if err != nil {
+			p.err = fmt.Errorf("sandbox: chroot: %w", err)
+			return p
+		}
+		if !info.IsDir() {
+			p.err = fmt.Errorf("sandbox: chroot: %s is not a directory", opts.Chroot)
+			return p
+		}
+	}
+	p.sandbox = &opts
+	return p
+}
@@ -0,0 +1,129 @@
+//go:build linux
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcWithSandbox(t *testing.T) {
+	if p := NewProc("true").WithSandbox(SandboxOptions{User: true}).Run(); p.Err() != nil {
+		t.Skipf("user namespaces not available: %s", p.Err())
+	}
+
+	tests := []struct {
+		name     string
+		cmd      string
+		opts     SandboxOptions
+		expected func(string) bool
+	}{
+		{
+			name:     "user namespace maps to root",
+			cmd:      "id -u",
+			opts:     SandboxOptions{User: true},
+			expected: func(out string) bool { return out == "0" },
+		},
+		{
+			name: "user namespace with mapping",
+			cmd:  "id -u",
+			opts: SandboxOptions{
+				User:        true,
+				UIDMappings: []IDMap{{ContainerID: 1000, HostID: os.Getuid(), Size: 1}},
+			},
+			expected: func(out string) bool { return out == "1000" },
+		},
+		{
+			name: "no network",
+			cmd:  "cat /proc/net/dev",
+			opts: SandboxOptions{User: true, NoNetwork: true},
+			expected: func(out string) bool {
+				lines := strings.Split(out, "\n")
+				return len(lines) == 3 && strings.HasPrefix(strings.TrimSpace(lines[2]), "lo:")
+			},
+		},
+		{
+			name: "private network",
+			cmd:  "cat /proc/net/dev",
+			opts: SandboxOptions{User: true, Network: true},
+			expected: func(out string) bool {
+				lines := strings.Split(out, "\n")
+				return len(lines) == 3 && strings.HasPrefix(strings.TrimSpace(lines[2]), "lo:")
+			},
+		},
+		{
+			name:     "pid namespace",
+			cmd:      `sh -c "echo $$"`,
+			opts:     SandboxOptions{User: true, Mount: true, PID: true, UTS: true},
+			expected: func(out string) bool { return out == "1" },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc(test.cmd).WithSandbox(test.opts).Run()
+			if err := p.Err(); err != nil {
+				t.Fatalf("%s: %s", err, p.Result())
+			}
+			if !test.expected(p.Result()) {
+				t.Errorf("unexpected output: %q", p.Result())
+			}
+		})
+	}
+}
+
+func TestProcWithSandboxCredentials(t *testing.T) {
+	if p := NewProc("true").WithSandbox(SandboxOptions{User: true}).Run(); p.Err() != nil {
+		t.Skipf("user namespaces not available: %s", p.Err())
+	}
+
+	// the default uid is the ID the host user is mapped to
+	opts := SandboxOptions{User: true, UIDMappings: []IDMap{{ContainerID: 1000, HostID: os.Getuid(), Size: 1}}}
+	p := NewProc("id -u").WithSandbox(opts).NoSetGroups()
+	p.groupid = new(int)
+	if err := p.Run().Err(); err != nil {
+		t.Fatalf("%s: %s", err, p.Result())
+	}
+	if p.Result() != "1000" {
+		t.Errorf("expecting uid 1000, got %q", p.Result())
+	}
+
+	// the host user is not mapped
+	opts.UIDMappings[0].HostID = os.Getuid() + 1
+	p = NewProc("id -u").WithSandbox(opts).NoSetGroups()
+	p.groupid = new(int)
+	if err := p.Run().Err(); err == nil || !strings.Contains(err.Error(), "is not mapped") {
+		t.Errorf("expecting unmapped uid error, got: %v", err)
+	}
+}
+
+func TestProcWithSandboxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SandboxOptions
+		err  string
+	}{
+		{name: "mappings without user namespace", opts: SandboxOptions{UIDMappings: []IDMap{{Size: 1}}}, err: "require a user namespace"},
+		{name: "missing chroot", opts: SandboxOptions{User: true, Chroot: "/does/not/exist"}, err: "chroot"},
+		{name: "chroot file", opts: SandboxOptions{User: true, Chroot: "/proc/self/exe"}, err: "not a directory"},
+		{name: "pivot root", opts: SandboxOptions{User: true, Mount: true, PivotRoot: "/tmp"}, err: "pivot_root is not supported"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewProc("true").WithSandbox(test.opts)
+			if p.Err() == nil || !strings.Contains(p.Err().Error(), test.err) {
+				t.Errorf("expecting error containing %q, got: %v", test.err, p.Err())
+			}
+		})
+	}
+
+	// privilege errors are explained
+	p := &Proc{sandbox: &SandboxOptions{User: true}}
+	err := p.startError(syscall.EPERM)
+	if !errors.Is(err, syscall.EPERM) || !strings.Contains(err.Error(), "sandbox namespaces") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// scriptVarRegex matches script lines that declare variables (i.e. NAME=value)
+var scriptVarRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// StepKind identifies the type of a script line
+type StepKind int
+
+const (
+	// StepCommand is a line that runs a command
+	StepCommand StepKind = iota
+	// StepVar is a line that declares a variable (NAME=value)
+	StepVar
+	// StepOption is a line that sets a script option (i.e. set -e)
+	StepOption
+)
+
+// String returns the name of the step kind
+func (k StepKind) String() string {
+	switch k {
+	case StepCommand:
+		return "command"
+	case StepVar:
+		return "var"
+	case StepOption:
+		return "option"
+	}
+	return "unknown"
+}
+
+// Step is the result of a single script line
+type Step struct {
+	// Line is the line number, in the script, where the step starts
+	Line int
+	// Text is the step source, with line continuations joined
+	Text string
+	Kind StepKind
+	// Proc is the process that ran a StepCommand
+	Proc     *Proc
+	Err      error
+	Duration time.Duration
+}
+
+// ScriptResult stores the result of a script execution
+type ScriptResult struct {
+	steps []*Step
+	err   error
+}
+
+// Err returns the error that stopped the script, if any. It is either an error
+// reading the script or, with errexit enabled, the error of the failed step.
+func (sr *ScriptResult) Err() error {
+	return sr.err
+}
+
+// Steps returns the steps executed by the script, in order
+func (sr *ScriptResult) Steps() []*Step {
+	return sr.steps
+}
+
+// FailedSteps returns the steps that completed with an error
+func (sr *ScriptResult) FailedSteps() (steps []*Step) {
+	for _, step := range sr.steps {
+		if step.Err != nil {
+			steps = append(steps, step)
+		}
+	}
+	return
+}
+
+// ScriptBuilder runs a line-oriented script where:
+//
+//	# lines starting with '#' are comments
+//	NAME=value          # sets a variable (see vars.Variables.Vars)
+//	set -e              # enables errexit (set +e disables it)
+//	set -x              # enables xtrace (set +x disables it)
+//	echo "hello $NAME"  # any other line runs as a command
+//	ls -l \
+//	  /tmp              # a trailing '\' continues a line
+//
+// Variables are set in the builder's variables and are expanded in subsequent lines.
+type ScriptBuilder struct {
+	ctx      context.Context
+	vars     *vars.Variables
+	source   io.Reader
+	err      error
+	errExit  bool
+	xtrace   bool
+	traceOut io.Writer
+	stdout   io.Writer
+	procHook func(*Proc)
+}
+
+// ScriptWithContextVars creates a *ScriptBuilder for the script source src using the
+// specified context and variables.
+func ScriptWithContextVars(ctx context.Context, variables *vars.Variables, src string) *ScriptBuilder {
+	return &ScriptBuilder{ctx: ctx, vars: variables, source: strings.NewReader(src), traceOut: os.Stderr}
+}
+
+// Script creates a *ScriptBuilder for the script source src
+func Script(src string) *ScriptBuilder {
+	return ScriptWithContextVars(context.Background(), vars.New(), src)
+}
+
+// ScriptFileWithContextVars creates a *ScriptBuilder for the script file at path using the
+// specified context and variables.
+func ScriptFileWithContextVars(ctx context.Context, variables *vars.Variables, path string) *ScriptBuilder {
+	sb := ScriptWithContextVars(ctx, variables, "")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		sb.err = err
+		return sb
+	}
+	sb.source = strings.NewReader(string(data))
+	return sb
+}
+
+// ScriptFile creates a *ScriptBuilder for the script file at path
+func ScriptFile(path string) *ScriptBuilder {
+	return ScriptFileWithContextVars(context.Background(), vars.New(), path)
+}
+
+// WithErrExit stops the script at the first failing line (same as set -e in the script)
+func (sb *ScriptBuilder) WithErrExit(errExit bool) *ScriptBuilder {
+	sb.errExit = errExit
+	return sb
+}
+
+// WithXTrace writes each command, after variable expansion, to out prefixed with '+'
+// before it runs (same as set -x in the script). A nil out disables tracing.
+func (sb *ScriptBuilder) WithXTrace(out io.Writer) *ScriptBuilder {
+	sb.xtrace = out != nil
+	if out != nil {
+		sb.traceOut = out
+	}
+	return sb
+}
+
+// WithStdout streams the combined output of each command to out.
+// By default, the output is captured and available from Step.Proc.
+func (sb *ScriptBuilder) WithStdout(out io.Writer) *ScriptBuilder {
+	sb.stdout = out
+	return sb
+}
+
+// WithProcHook sets a function that is called with each process before it starts
+func (sb *ScriptBuilder) WithProcHook(hook func(*Proc)) *ScriptBuilder {
+	sb.procHook = hook
+	return sb
+}
+
+// Run runs the script, line by line, and returns a *ScriptResult with a Step per line.
+// Execution continues after a failing line unless errexit is enabled.
+func (sb *ScriptBuilder) Run() *ScriptResult {
+	result := new(ScriptResult)
+	if sb.err != nil {
+		result.err = sb.err
+		return result
+	}
+
+	lines, err := parseScript(sb.source)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	for _, line := range lines {
+		if err := sb.context().Err(); err != nil {
+			result.err = err
+			return result
+		}
+
+		step := &Step{Line: line.num, Text: line.text}
+		start := time.Now()
+		sb.runStep(step)
+		step.Duration = time.Since(start)
+		result.steps = append(result.steps, step)
+
+		if step.Err != nil && sb.errExit {
+			result.err = fmt.Errorf("line %d: %s: %w", step.Line, step.Text, step.Err)
+			return result
+		}
+	}
+	return result
+}
+
+// runStep runs a single script line
+func (sb *ScriptBuilder) runStep(step *Step) {
+	switch {
+	case scriptVarRegex.MatchString(step.Text):
+		step.Kind = StepVar
+		step.Err = sb.vars.Vars(step.Text).Err()
+
+	case strings.HasPrefix(step.Text, "set "):
+		step.Kind = StepOption
+		for _, opt := range strings.Fields(step.Text)[1:] {
+			switch opt {
+			case "-e":
+				sb.errExit = true
+			case "+e":
+				sb.errExit = false
+			case "-x":
+				sb.xtrace = true
+			case "+x":
+				sb.xtrace = false
+			default:
+				step.Err = fmt.Errorf("unsupported option %q", opt)
+				return
+			}
+		}
+
+	default:
+		step.Kind = StepCommand
+		cmdStr := sb.vars.ExpandCommand(step.Text)
+		if sb.xtrace {
+			fmt.Fprintf(sb.traceOut, "+ %s\n", cmdStr)
+		}
+
+		proc := NewProcWithContext(sb.context(), cmdStr)
+		proc.vars = sb.vars
+		step.Proc = proc
+		if sb.stdout != nil && proc.Err() == nil {
+			proc.cmd.Stdout, proc.cmd.Stderr = sb.stdout, sb.stdout
+		}
+		if sb.procHook != nil {
+			sb.procHook(proc)
+		}
+		step.Err = proc.Run().Err()
+	}
+}
+
+func (sb *ScriptBuilder) context() context.Context {
+	if sb.ctx == nil {
+		return context.Background()
+	}
+	return sb.ctx
+}
+
+// scriptLine is a logical script line, with continuations joined
+type scriptLine struct {
+	num  int
+	text string
+}
+
+// parseScript reads the logical lines of a script, skipping blank and comment lines
+func parseScript(source io.Reader) ([]scriptLine, error) {
+	var lines []scriptLine
+	var current strings.Builder
+	start, num := 0, 0
+
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		num++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if current.Len() == 0 {
+			start = num
+			text = strings.TrimSpace(text)
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+		}
+
+		// an odd number of trailing backslashes continues the line
+		if trailing := len(text) - len(strings.TrimRight(text, `\`)); trailing%2 == 1 {
+			current.WriteString(text[:len(text)-1])
+			continue
+		}
+		current.WriteString(text)
+		lines = append(lines, scriptLine{num: start, text: strings.TrimSpace(current.String())})
+		current.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current.Len() > 0 {
+		return nil, fmt.Errorf("line %d: unterminated line continuation", start)
+	}
+	return lines, nil
+}
@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected []scriptLine
+		errored  bool
+	}{
+		{
+			name:     "comments and blanks",
+			script:   "#!/usr/bin/env gexe\n\n# comment\n  echo hello  \n\t# indented comment\n",
+			expected: []scriptLine{{num: 4, text: "echo hello"}},
+		},
+		{
+			name:   "continuations",
+			script: "NAME=world\nls -l \\\n  /tmp \\\n  /var\r\necho done",
+			expected: []scriptLine{
+				{num: 1, text: "NAME=world"},
+				{num: 2, text: "ls -l   /tmp   /var"},
+				{num: 5, text: "echo done"},
+			},
+		},
+		{
+			name:     "escaped backslash",
+			script:   "echo a\\\\\necho b",
+			expected: []scriptLine{{num: 1, text: `echo a\\`}, {num: 2, text: "echo b"}},
+		},
+		{
+			name:    "unterminated continuation",
+			script:  "echo a\necho b \\",
+			errored: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, err := parseScript(strings.NewReader(test.script))
+			if test.errored {
+				if err == nil {
+					t.Fatal("expecting error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lines, test.expected) {
+				t.Errorf("expecting %#v, got %#v", test.expected, lines)
+			}
+		})
+	}
+}
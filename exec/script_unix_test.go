@@ -0,0 +1,126 @@
+//go:build !windows
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+func TestScriptBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		errExit bool
+		steps   int
+		failed  int
+		errored bool
+		test    func(*testing.T, *ScriptResult)
+	}{
+		{
+			name:   "vars and commands",
+			script: "# greeting\nNAME=world\nMSG=\"hello ${NAME}\"\necho $MSG \\\n  again",
+			steps:  3,
+			test: func(t *testing.T, result *ScriptResult) {
+				step := result.Steps()[2]
+				if step.Kind != StepCommand || step.Line != 4 {
+					t.Errorf("unexpected step: %+v", step)
+				}
+				if step.Proc.Result() != "hello world again" {
+					t.Errorf("unexpected result: %s", step.Proc.Result())
+				}
+			},
+		},
+		{
+			name:   "continue on error",
+			script: "false\necho after",
+			steps:  2,
+			failed: 1,
+		},
+		{
+			name:    "errexit option",
+			script:  "false\necho after",
+			errExit: true,
+			steps:   1,
+			failed:  1,
+			errored: true,
+		},
+		{
+			name:    "errexit in script",
+			script:  "false\nset -e\necho ok\nfalse\necho after",
+			steps:   4,
+			failed:  2,
+			errored: true,
+			test: func(t *testing.T, result *ScriptResult) {
+				if !strings.HasPrefix(result.Err().Error(), "line 4: false") {
+					t.Errorf("unexpected error: %s", result.Err())
+				}
+			},
+		},
+		{
+			name:   "unsupported option",
+			script: "set -o pipefail",
+			steps:  1,
+			failed: 1,
+		},
+		{
+			name:    "parse error",
+			script:  "echo a \\",
+			errored: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ScriptWithContextVars(context.Background(), vars.New(), test.script).WithErrExit(test.errExit).Run()
+			if (result.Err() != nil) != test.errored {
+				t.Fatalf("unexpected error: %v", result.Err())
+			}
+			if len(result.Steps()) != test.steps {
+				t.Fatalf("expecting %d steps, got %d", test.steps, len(result.Steps()))
+			}
+			if len(result.FailedSteps()) != test.failed {
+				t.Errorf("expecting %d failed steps, got %d", test.failed, len(result.FailedSteps()))
+			}
+			if test.test != nil {
+				test.test(t, result)
+			}
+		})
+	}
+}
+
+func TestScriptBuilder_XTraceStdout(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "run.gexe")
+	if err := os.WriteFile(script, []byte("DIR=/tmp\nset -x\necho in $DIR\nset +x\necho quiet\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var trace, out bytes.Buffer
+	if err := ScriptFile(script).WithXTrace(&trace).WithStdout(&out).Run().Err(); err != nil {
+		t.Fatal(err)
+	}
+	if trace.String() != "+ echo in /tmp\n" {
+		t.Errorf("unexpected trace: %q", trace.String())
+	}
+	if out.String() != "in /tmp\nquiet\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	trace.Reset()
+	if err := Script("echo a\necho b").WithXTrace(&trace).Run().Err(); err != nil {
+		t.Fatal(err)
+	}
+	if trace.String() != "+ echo a\n+ echo b\n" {
+		t.Errorf("unexpected trace: %q", trace.String())
+	}
+
+	if err := ScriptFile(filepath.Join(t.TempDir(), "missing")).Run().Err(); err == nil {
+		t.Error("expecting error for missing script file")
+	}
+}
@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StepResult is the unified result of a command or a Go function executed by a CommandBuilder
+type StepResult struct {
+	// Name is the name of a function step, or the command line of a command step
+	Name string
+	// Proc is the process of a command step, it is nil for a function step
+	Proc     *Proc
+	Err      error
+	Duration time.Duration
+}
+
+// funcStep is a Go function executed as a step of a CommandBuilder
+type funcStep struct {
+	name     string
+	fn       func(context.Context) error
+	index    int // number of processes added to the builder before the function
+	mu       sync.RWMutex
+	err      error
+	duration time.Duration
+}
+
+// run calls the function with ctx, a panic is reported as an error
+func (f *funcStep) run(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: panic: %v", f.name, r)
+		}
+		f.mu.Lock()
+		f.err, f.duration = err, time.Since(start)
+		f.mu.Unlock()
+	}()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.fn(ctx)
+}
+
+func (f *funcStep) result() StepResult {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return StepResult{Name: f.name, Err: f.err, Duration: f.duration}
+}
+
+// builderStep is either a process or a function of a CommandBuilder
+type builderStep struct {
+	proc *Proc
+	fn   *funcStep
+}
+
+func (s builderStep) result() StepResult {
+	if s.fn != nil {
+		return s.fn.result()
+	}
+	name := ""
+	if s.proc.cmd != nil {
+		name = strings.Join(s.proc.cmd.Args, " ")
+	}
+	return StepResult{Name: name, Proc: s.proc, Err: s.proc.Err(), Duration: s.proc.Duration()}
+}
+
+// AddFunc adds a Go function, identified by name, that runs as a step of the builder alongside
+// its commands, in the order it was added. The function receives the builder's context and
+// should return when it is done. A returned error (or a panic) fails the step like a failed
+// command (see CommandResult.Results):
+//
+//	exec.Commands("make build").
+//		AddFunc("upload", func(ctx context.Context) error { return upload(ctx, "bin/app") }).
+//		Add("make clean").
+//		WithPolicy(exec.ExitOnErrPolicy).Run()
+func (cb *CommandBuilder) AddFunc(name string, fn func(ctx context.Context) error) *CommandBuilder {
+	cb.funcs = append(cb.funcs, &funcStep{name: name, fn: fn, index: len(cb.procs)})
+	return cb
+}
+
+// WithConcurrency limits the number of steps (commands and functions) that run at the same time
+// with the ConcurrentExecPolicy. A value <= 0 (the default) does not limit concurrency.
+func (cb *CommandBuilder) WithConcurrency(n int) *CommandBuilder {
+	cb.concurrency = n
+	return cb
+}
+
+// steps returns the processes and functions of the builder in the order they were added
+func (cb *CommandBuilder) steps() []builderStep {
+	steps := make([]builderStep, 0, len(cb.procs)+len(cb.funcs))
+	next := 0
+	for i, proc := range cb.procs {
+		for ; next < len(cb.funcs) && cb.funcs[next].index <= i; next++ {
+			steps = append(steps, builderStep{fn: cb.funcs[next]})
+		}
+		steps = append(steps, builderStep{proc: proc})
+	}
+	for ; next < len(cb.funcs); next++ {
+		steps = append(steps, builderStep{fn: cb.funcs[next]})
+	}
+	return steps
+}
+
+// runStep runs a step, whose process must be set up with setupProc, to completion.
+// A running process is killed when ctx is done.
+func (cb *CommandBuilder) runStep(ctx context.Context, step builderStep) error {
+	if step.fn != nil {
+		return step.fn.run(ctx)
+	}
+
+	if err := step.proc.Start().Err(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { step.proc.Signal(os.Kill) })
+	defer stop()
+	return step.proc.Wait().Err()
+}
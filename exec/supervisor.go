@@ -0,0 +1,398 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vladimirvivien/gexe/fs"
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// RestartPolicy determines when a supervised service is restarted
+type RestartPolicy byte
+
+const (
+	RestartNever RestartPolicy = iota
+	RestartAlways
+	RestartOnFailure
+)
+
+// SupervisorEventType identifies a supervisor lifecycle event
+type SupervisorEventType byte
+
+const (
+	ServiceStarted SupervisorEventType = iota
+	ServiceExited
+	ServiceRestarting
+	ServiceStopped
+)
+
+// String returns a readable representation of the event type
+func (t SupervisorEventType) String() string {
+	switch t {
+	case ServiceStarted:
+		return "started"
+	case ServiceExited:
+		return "exited"
+	case ServiceRestarting:
+		return "restarting"
+	case ServiceStopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+// SupervisorEvent reports a change in a supervised service
+type SupervisorEvent struct {
+	Service  string
+	Type     SupervisorEventType
+	Pid      int
+	ExitCode int
+	Restarts int
+	Backoff  time.Duration
+	Err      error
+	Time     time.Time
+}
+
+// String returns a readable representation of the event
+func (e SupervisorEvent) String() string {
+	switch e.Type {
+	case ServiceStarted:
+		return fmt.Sprintf("%s: started (pid %d)", e.Service, e.Pid)
+	case ServiceExited:
+		if e.Err != nil {
+			return fmt.Sprintf("%s: exited (code %d): %s", e.Service, e.ExitCode, e.Err)
+		}
+		return fmt.Sprintf("%s: exited (code %d)", e.Service, e.ExitCode)
+	case ServiceRestarting:
+		return fmt.Sprintf("%s: restarting in %s (restart %d)", e.Service, e.Backoff, e.Restarts)
+	}
+	return fmt.Sprintf("%s: %s", e.Service, e.Type)
+}
+
+// Service is a named command kept alive by a Supervisor
+type Service struct {
+	name        string
+	cmdStr      string
+	policy      RestartPolicy
+	maxRestarts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	logPath     string
+	logMaxSize  int64
+	logBackups  int
+	output      io.Writer
+
+	mu       sync.Mutex
+	proc     *Proc
+	restarts int
+}
+
+// WithRestart sets the restart policy of the service (default: RestartOnFailure)
+func (s *Service) WithRestart(policy RestartPolicy) *Service {
+	s.policy = policy
+	return s
+}
+
+// WithMaxRestarts limits the number of restarts after which the service is
+// no longer restarted. A value of 0 (default) means unlimited restarts.
+func (s *Service) WithMaxRestarts(n int) *Service {
+	s.maxRestarts = n
+	return s
+}
+
+// WithBackoff sets the delay before a restart. The delay starts at min and
+// doubles after each consecutive restart up to max (default: 1s to 30s).
+func (s *Service) WithBackoff(min, max time.Duration) *Service {
+	s.minBackoff, s.maxBackoff = min, max
+	return s
+}
+
+// WithLogFile sends the combined output of the service to a log file that is rotated
+// when it exceeds maxSize bytes, keeping maxBackups rotated files (see fs.RotatingWriter).
+func (s *Service) WithLogFile(path string, maxSize int64, maxBackups int) *Service {
+	s.logPath, s.logMaxSize, s.logBackups = path, maxSize, maxBackups
+	return s
+}
+
+// WithOutput sends the combined output of the service to w
+func (s *Service) WithOutput(w io.Writer) *Service {
+	s.output = w
+	return s
+}
+
+// Name returns the service name
+func (s *Service) Name() string {
+	return s.name
+}
+
+// Proc returns the current (or last) process of the service
+func (s *Service) Proc() *Proc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proc
+}
+
+// Restarts returns the number of times the service has been restarted
+func (s *Service) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+// backoff returns the restart delay after n consecutive restarts
+func (s *Service) backoff(n int) time.Duration {
+	delay := s.minBackoff
+	for i := 1; i < n && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	return delay
+}
+
+// Supervisor keeps a set of services running according to their restart policies
+type Supervisor struct {
+	vars       *vars.Variables
+	services   []*Service
+	events     chan SupervisorEvent
+	eventsOnce sync.Once
+	stopChan   chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+	err        error
+	procHook   func(*Proc)
+}
+
+// NewSupervisorWithVars creates a *Supervisor that expands service commands with the session variables
+func NewSupervisorWithVars(variables *vars.Variables) *Supervisor {
+	return &Supervisor{
+		vars:     variables,
+		events:   make(chan SupervisorEvent, 128),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// NewSupervisor creates a new *Supervisor
+func NewSupervisor() *Supervisor {
+	return NewSupervisorWithVars(vars.New())
+}
+
+// Add declares a service, named name, that runs cmdStr
+func (s *Supervisor) Add(name, cmdStr string) *Service {
+	svc := &Service{
+		name:       name,
+		cmdStr:     cmdStr,
+		policy:     RestartOnFailure,
+		minBackoff: time.Second,
+		maxBackoff: 30 * time.Second,
+		output:     io.Discard,
+	}
+	s.services = append(s.services, svc)
+	return svc
+}
+
+// WithProcHook sets a function that is called with each service process before it starts
+func (s *Supervisor) WithProcHook(hook func(*Proc)) *Supervisor {
+	s.procHook = hook
+	return s
+}
+
+// Services returns the supervised services
+func (s *Supervisor) Services() []*Service {
+	return s.services
+}
+
+// Events returns a channel of service lifecycle events. The channel is buffered and closed
+// after shutdown, or when all services have stopped. Events are dropped if the buffer is full.
+func (s *Supervisor) Events() <-chan SupervisorEvent {
+	return s.events
+}
+
+// Err returns an error that prevented the supervisor from starting
+func (s *Supervisor) Err() error {
+	return s.err
+}
+
+// Start starts all services and returns immediately. Log files are opened before any
+// service is started, a log file that cannot be opened prevents all services from starting.
+func (s *Supervisor) Start() *Supervisor {
+	outputs := make([]io.Writer, len(s.services))
+	for i, svc := range s.services {
+		outputs[i] = svc.output
+		if svc.logPath == "" {
+			continue
+		}
+		writer, err := fs.NewRotatingWriter(s.vars.Eval(svc.logPath), svc.logMaxSize, svc.logBackups)
+		if err != nil {
+			for j, output := range outputs[:i] {
+				if closer, ok := output.(io.Closer); ok && output != s.services[j].output {
+					closer.Close()
+				}
+			}
+			s.err = fmt.Errorf("service %s: %w", svc.name, err)
+			s.Shutdown(0)
+			return s
+		}
+		outputs[i] = writer
+	}
+
+	for i, svc := range s.services {
+		s.wg.Add(1)
+		go s.supervise(svc, outputs[i])
+	}
+
+	// close the events channel when all services have stopped on their own
+	go func() {
+		s.wg.Wait()
+		s.closeEvents()
+	}()
+	return s
+}
+
+// Wait blocks until all services have stopped, either on their own
+// (according to their restart policies) or after Supervisor.Shutdown.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Shutdown stops restarting services and terminates running processes. Each process
+// is sent SIGTERM and killed if it is still running after grace. Shutdown blocks until
+// all services have stopped, then closes the events channel.
+func (s *Supervisor) Shutdown(grace time.Duration) {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+
+		for _, svc := range s.services {
+			if proc := svc.Proc(); proc != nil && proc.IsRunning() {
+				if err := proc.Signal(syscall.SIGTERM); err != nil {
+					_ = proc.Signal(os.Kill)
+				}
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(grace):
+			for _, svc := range s.services {
+				if proc := svc.Proc(); proc != nil && proc.IsRunning() {
+					_ = proc.Signal(os.Kill)
+				}
+			}
+			<-done
+		}
+		s.closeEvents()
+	})
+}
+
+// closeEvents closes the events channel once all services have stopped
+func (s *Supervisor) closeEvents() {
+	s.eventsOnce.Do(func() { close(s.events) })
+}
+
+// supervise runs the service and restarts it according to its policy
+func (s *Supervisor) supervise(svc *Service, output io.Writer) {
+	defer s.wg.Done()
+	if closer, ok := output.(io.Closer); ok && output != svc.output {
+		defer closer.Close()
+	}
+
+	consecutive := 0
+	for {
+		proc := NewProcWithContextVars(context.Background(), svc.cmdStr, s.vars).SetProcGroup()
+		proc.SetStdout(output)
+		proc.SetStderr(output)
+		if s.procHook != nil {
+			s.procHook(proc)
+		}
+
+		// hold the service lock until the process is started, so that Shutdown,
+		// which looks up the process after stopping, cannot miss it
+		svc.mu.Lock()
+		svc.proc = proc
+		if s.stopped() {
+			svc.mu.Unlock()
+			return
+		}
+		started := time.Now()
+		err := proc.Start().Err()
+		svc.mu.Unlock()
+
+		if err != nil {
+			s.emit(SupervisorEvent{Service: svc.name, Type: ServiceExited, ExitCode: -1, Err: err})
+		} else {
+			s.emit(SupervisorEvent{Service: svc.name, Type: ServiceStarted, Pid: proc.ID()})
+			proc.Wait()
+			s.emit(SupervisorEvent{Service: svc.name, Type: ServiceExited, Pid: proc.ID(), ExitCode: proc.ExitCode(), Err: proc.Err()})
+		}
+
+		if s.stopped() || !svc.shouldRestart(proc) {
+			s.emit(SupervisorEvent{Service: svc.name, Type: ServiceStopped, Restarts: svc.Restarts()})
+			return
+		}
+
+		// reset the backoff when the process ran longer than the maximum backoff
+		if time.Since(started) > svc.maxBackoff {
+			consecutive = 0
+		}
+		consecutive++
+
+		svc.mu.Lock()
+		svc.restarts++
+		restarts := svc.restarts
+		svc.mu.Unlock()
+
+		backoff := svc.backoff(consecutive)
+		s.emit(SupervisorEvent{Service: svc.name, Type: ServiceRestarting, Restarts: restarts, Backoff: backoff})
+		select {
+		case <-time.After(backoff):
+		case <-s.stopChan:
+			s.emit(SupervisorEvent{Service: svc.name, Type: ServiceStopped, Restarts: restarts})
+			return
+		}
+	}
+}
+
+// shouldRestart applies the service restart policy to the exited process
+func (svc *Service) shouldRestart(proc *Proc) bool {
+	if svc.maxRestarts > 0 && svc.Restarts() >= svc.maxRestarts {
+		return false
+	}
+	switch svc.policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return proc.Err() != nil
+	}
+	return false
+}
+
+func (s *Supervisor) stopped() bool {
+	select {
+	case <-s.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// emit sends the event without blocking, dropping it if the buffer is full
+func (s *Supervisor) emit(event SupervisorEvent) {
+	event.Time = time.Now()
+	select {
+	case s.events <- event:
+	default:
+	}
+}
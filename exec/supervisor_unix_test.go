@@ -0,0 +1,180 @@
+//go:build !windows
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSupervisor_RestartPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmdStr      string
+		policy      RestartPolicy
+		maxRestarts int
+		restarts    int
+	}{
+		{name: "never", cmdStr: `/bin/sh -c "exit 1"`, policy: RestartNever, restarts: 0},
+		{name: "on-failure with success", cmdStr: "true", policy: RestartOnFailure, restarts: 0},
+		{name: "on-failure max restarts", cmdStr: `/bin/sh -c "exit 1"`, policy: RestartOnFailure, maxRestarts: 2, restarts: 2},
+		{name: "always max restarts", cmdStr: "true", policy: RestartAlways, maxRestarts: 3, restarts: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sup := NewSupervisor()
+			svc := sup.Add("svc", test.cmdStr).
+				WithRestart(test.policy).
+				WithMaxRestarts(test.maxRestarts).
+				WithBackoff(10*time.Millisecond, 40*time.Millisecond)
+			if err := sup.Start().Err(); err != nil {
+				t.Fatal(err)
+			}
+			sup.Wait()
+			sup.Shutdown(time.Second)
+
+			if svc.Restarts() != test.restarts {
+				t.Errorf("expecting %d restarts, got %d", test.restarts, svc.Restarts())
+			}
+
+			counts := make(map[SupervisorEventType]int)
+			for event := range sup.Events() {
+				counts[event.Type]++
+			}
+			if counts[ServiceStarted] != test.restarts+1 {
+				t.Errorf("expecting %d started events, got %d", test.restarts+1, counts[ServiceStarted])
+			}
+			if counts[ServiceRestarting] != test.restarts {
+				t.Errorf("expecting %d restarting events, got %d", test.restarts, counts[ServiceRestarting])
+			}
+			if counts[ServiceStopped] != 1 {
+				t.Errorf("expecting 1 stopped event, got %d", counts[ServiceStopped])
+			}
+		})
+	}
+}
+
+func TestSupervisor_Shutdown(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "svc.log")
+	sup := NewSupervisor()
+	sup.Add("sleeper", `/bin/sh -c "echo hello; sleep 10"`).WithRestart(RestartAlways).WithLogFile(logFile, 1024, 1)
+	sup.Add("stubborn", `/bin/sh -c "trap '' TERM; sleep 10"`).WithRestart(RestartAlways)
+	if err := sup.Start().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := 0
+	for event := range sup.Events() {
+		if event.Type == ServiceStarted {
+			started++
+		}
+		if started == 2 {
+			break
+		}
+	}
+
+	// wait for output to be logged
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(logFile)
+		if strings.TrimSpace(string(data)) == "hello" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected log content: %q", string(data))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	start := time.Now()
+	sup.Shutdown(300 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("shutdown took too long: %s", elapsed)
+	}
+	for _, svc := range sup.Services() {
+		if svc.Proc().IsRunning() {
+			t.Errorf("service %s still running after shutdown", svc.Name())
+		}
+		if svc.Restarts() != 0 {
+			t.Errorf("service %s restarted during shutdown", svc.Name())
+		}
+	}
+}
+
+func TestSupervisor_ShutdownDuringRestart(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		// the service fails once, then runs until shut down
+		marker := filepath.Join(t.TempDir(), "failed")
+		sup := NewSupervisor()
+		sup.Add("svc", fmt.Sprintf(`/bin/sh -c "[ -f %s ] && exec sleep 30; touch %s; exit 1"`, marker, marker)).
+			WithBackoff(5*time.Millisecond, 5*time.Millisecond)
+		if err := sup.Start().Err(); err != nil {
+			t.Fatal(err)
+		}
+		for event := range sup.Events() {
+			if event.Type == ServiceRestarting {
+				break
+			}
+		}
+
+		// shut down around the time the service is started again
+		time.Sleep(time.Duration(i) * 100 * time.Microsecond)
+		done := make(chan struct{})
+		go func() {
+			sup.Shutdown(time.Minute)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("iteration %d: shutdown missed the restarted process", i)
+		}
+		if proc := sup.Services()[0].Proc(); proc.IsRunning() {
+			t.Fatalf("iteration %d: service still running after shutdown", i)
+		}
+	}
+}
+
+func TestSupervisor_LogFileError(t *testing.T) {
+	sup := NewSupervisor()
+	first := sup.Add("first", "sleep 10")
+	sup.Add("second", "sleep 10").WithLogFile(filepath.Join(os.DevNull, "svc.log"), 1024, 1)
+	if err := sup.Start().Err(); err == nil {
+		t.Fatal("expecting log file error")
+	}
+	if first.Proc() != nil {
+		t.Error("expecting no service to be started")
+	}
+}
+
+func TestSupervisor_EventsClosed(t *testing.T) {
+	sup := NewSupervisor()
+	sup.Add("svc", "true").WithRestart(RestartNever)
+	if err := sup.Start().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the events channel is closed once all services stopped on their own
+	done := make(chan []SupervisorEventType)
+	go func() {
+		var types []SupervisorEventType
+		for event := range sup.Events() {
+			types = append(types, event.Type)
+		}
+		done <- types
+	}()
+	select {
+	case types := <-done:
+		if len(types) != 3 || types[2] != ServiceStopped {
+			t.Errorf("unexpected events: %v", types)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events channel not closed")
+	}
+	sup.Shutdown(time.Second)
+}
@@ -0,0 +1,366 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/gexe/fs"
+)
+
+// TaskStatus represents the execution status of a task
+type TaskStatus byte
+
+const (
+	TaskPending TaskStatus = iota
+	TaskRunning
+	TaskSucceeded
+	TaskFailed
+	TaskSkipped
+	TaskUpToDate
+)
+
+// String returns a readable representation of the status
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskPending:
+		return "pending"
+	case TaskRunning:
+		return "running"
+	case TaskSucceeded:
+		return "succeeded"
+	case TaskFailed:
+		return "failed"
+	case TaskSkipped:
+		return "skipped"
+	case TaskUpToDate:
+		return "up-to-date"
+	}
+	return "unknown"
+}
+
+// Task is a named unit of work, in a CommandBuilder task graph, made of one
+// or more commands executed sequentially. A task starts only after all
+// of the tasks it depends on have succeeded.
+type Task struct {
+	name     string
+	procs    []*Proc
+	deps     []string
+	status   TaskStatus
+	err      error
+	duration time.Duration
+	done     chan struct{}
+	targets  *fs.Targets
+}
+
+// After declares the names of the tasks that must complete successfully before this task can run
+func (t *Task) After(names ...string) *Task {
+	t.deps = append(t.deps, names...)
+	return t
+}
+
+// Inputs declares the input files, or glob patterns, consumed by the task.
+// Together with Task.Outputs, it allows the task to be skipped when its outputs are up to date.
+func (t *Task) Inputs(paths ...string) *Task {
+	t.targets.Inputs(paths...)
+	return t
+}
+
+// Outputs declares the files produced by the task. The task is skipped, with status
+// TaskUpToDate, when all outputs are newer than every input (see fs.Targets).
+func (t *Task) Outputs(paths ...string) *Task {
+	t.targets.Outputs(paths...)
+	return t
+}
+
+// WithHashState compares input content hashes, stored in stateFile, instead of
+// modification times to determine whether the task outputs are up to date.
+func (t *Task) WithHashState(stateFile string) *Task {
+	t.targets.WithHashState(stateFile)
+	return t
+}
+
+// Name returns the task name
+func (t *Task) Name() string {
+	return t.name
+}
+
+// Deps returns the names of the tasks this task depends on
+func (t *Task) Deps() []string {
+	return t.deps
+}
+
+// Procs returns the processes executed by the task
+func (t *Task) Procs() []*Proc {
+	return t.procs
+}
+
+// Status returns the task execution status
+func (t *Task) Status() TaskStatus {
+	return t.status
+}
+
+// Err returns the error that caused the task to fail or be skipped
+func (t *Task) Err() error {
+	return t.err
+}
+
+// Duration returns how long the task took to execute
+func (t *Task) Duration() time.Duration {
+	return t.duration
+}
+
+// TaskResult stores the result of a task graph execution
+type TaskResult struct {
+	plan  [][]string
+	tasks []*Task
+	err   error
+}
+
+// Err returns a structural error (i.e. unknown dependency or cycle) that prevented execution
+func (tr *TaskResult) Err() error {
+	return tr.err
+}
+
+// Plan returns the execution plan as successive stages of task names.
+// Tasks in the same stage have no dependencies on each other.
+func (tr *TaskResult) Plan() [][]string {
+	return tr.plan
+}
+
+// Tasks returns all tasks in the graph in declaration order
+func (tr *TaskResult) Tasks() []*Task {
+	return tr.tasks
+}
+
+// Task returns the named task or nil if not found
+func (tr *TaskResult) Task(name string) *Task {
+	for _, task := range tr.tasks {
+		if task.name == name {
+			return task
+		}
+	}
+	return nil
+}
+
+// FailedTasks returns tasks that failed or were skipped because of a failed dependency
+func (tr *TaskResult) FailedTasks() (tasks []*Task) {
+	for _, task := range tr.tasks {
+		if task.status == TaskFailed || task.status == TaskSkipped {
+			tasks = append(tasks, task)
+		}
+	}
+	return
+}
+
+// Procs returns all processes from all tasks
+func (tr *TaskResult) Procs() (procs []*Proc) {
+	for _, task := range tr.tasks {
+		procs = append(procs, task.procs...)
+	}
+	return
+}
+
+// ErrProcs returns processes that failed
+func (tr *TaskResult) ErrProcs() (procs []*Proc) {
+	for _, task := range tr.tasks {
+		for _, proc := range task.procs {
+			if proc.Err() != nil {
+				procs = append(procs, proc)
+			}
+		}
+	}
+	return
+}
+
+// Tree returns a textual tree of the task graph, starting from tasks
+// with no dependencies, where each task lists its dependents and status:
+//
+//	build [succeeded]
+//	└── test [failed]
+//	    └── release [skipped]
+func (tr *TaskResult) Tree() string {
+	dependents := make(map[string][]*Task)
+	for _, task := range tr.tasks {
+		for _, dep := range task.deps {
+			dependents[dep] = append(dependents[dep], task)
+		}
+	}
+
+	var sb strings.Builder
+	var walk func(task *Task, prefix string, last, root bool)
+	walk = func(task *Task, prefix string, last, root bool) {
+		childPrefix := prefix
+		switch {
+		case root:
+		case last:
+			sb.WriteString(prefix + "└── ")
+			childPrefix = prefix + "    "
+		default:
+			sb.WriteString(prefix + "├── ")
+			childPrefix = prefix + "│   "
+		}
+		fmt.Fprintf(&sb, "%s [%s]\n", task.name, task.status)
+		children := dependents[task.name]
+		for i, child := range children {
+			walk(child, childPrefix, i == len(children)-1, false)
+		}
+	}
+
+	for _, task := range tr.tasks {
+		if len(task.deps) == 0 {
+			walk(task, "", true, true)
+		}
+	}
+	return sb.String()
+}
+
+// Task declares a named task, made of commands cmdStrs, in the builder's task graph.
+// If the task already exists, the commands are appended to it. Use Task.After to declare
+// dependencies and CommandBuilder.RunTasks to execute the graph.
+func (cb *CommandBuilder) Task(name string, cmdStrs ...string) *Task {
+	task, ok := cb.tasks[name]
+	if !ok {
+		task = &Task{name: name, targets: fs.TargetsWithVars(cb.vars)}
+		if cb.tasks == nil {
+			cb.tasks = make(map[string]*Task)
+		}
+		cb.tasks[name] = task
+		cb.taskOrder = append(cb.taskOrder, task)
+	}
+	for _, cmd := range cmdStrs {
+		task.procs = append(task.procs, NewProcWithContextVars(cb.context(), cmd, cb.vars).AllowExitCodes(cb.allowCodes...))
+	}
+	return task
+}
+
+// RunTasks executes the task graph and waits for all tasks to complete. Independent tasks
+// are executed concurrently while dependent tasks wait for their dependencies. When a task
+// fails, all of the tasks that depend on it (directly or indirectly) are skipped.
+func (cb *CommandBuilder) RunTasks() *TaskResult {
+	result := &TaskResult{tasks: cb.taskOrder}
+
+	plan, err := cb.planTasks()
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.plan = plan
+
+	for _, task := range cb.taskOrder {
+		task.status = TaskPending
+		task.err = nil
+		task.done = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range cb.taskOrder {
+		wg.Add(1)
+		go func(task *Task) {
+			defer wg.Done()
+			defer close(task.done)
+			for _, dep := range task.deps {
+				depTask := cb.tasks[dep]
+				<-depTask.done
+				if depTask.status != TaskSucceeded && depTask.status != TaskUpToDate {
+					task.status = TaskSkipped
+					task.err = fmt.Errorf("task %s: dependency %s %s", task.name, dep, depTask.status)
+					return
+				}
+			}
+			cb.runTask(task)
+		}(task)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// runTask executes the commands of the task sequentially, stopping on the first error
+func (cb *CommandBuilder) runTask(task *Task) {
+	task.status = TaskRunning
+	start := time.Now()
+	defer func() { task.duration = time.Since(start) }()
+
+	if task.targets.UpToDate() {
+		task.status = TaskUpToDate
+		return
+	}
+	if err := task.targets.Err(); err != nil {
+		task.status = TaskFailed
+		task.err = fmt.Errorf("task %s: %w", task.name, err)
+		return
+	}
+
+	for _, proc := range task.procs {
+		if err := cb.runCommand(proc); err != nil {
+			task.status = TaskFailed
+			task.err = fmt.Errorf("task %s: %w", task.name, err)
+			return
+		}
+	}
+
+	if err := task.targets.Record(); err != nil {
+		task.status = TaskFailed
+		task.err = fmt.Errorf("task %s: %w", task.name, err)
+		return
+	}
+	task.status = TaskSucceeded
+}
+
+// planTasks validates the task graph and groups tasks into stages
+// where each stage only depends on previous stages.
+func (cb *CommandBuilder) planTasks() ([][]string, error) {
+	inDegree := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, task := range cb.taskOrder {
+		inDegree[task.name] += 0
+		for _, dep := range task.deps {
+			if _, ok := cb.tasks[dep]; !ok {
+				return nil, fmt.Errorf("task %s: unknown dependency %s", task.name, dep)
+			}
+			inDegree[task.name]++
+			dependents[dep] = append(dependents[dep], task.name)
+		}
+	}
+
+	var plan [][]string
+	var stage []string
+	for _, task := range cb.taskOrder {
+		if inDegree[task.name] == 0 {
+			stage = append(stage, task.name)
+		}
+	}
+
+	planned := 0
+	for len(stage) > 0 {
+		sort.Strings(stage)
+		plan = append(plan, stage)
+		planned += len(stage)
+
+		var next []string
+		for _, name := range stage {
+			for _, dependent := range dependents[name] {
+				inDegree[dependent]--
+				if inDegree[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		stage = next
+	}
+
+	if planned != len(cb.taskOrder) {
+		var cyclic []string
+		for _, task := range cb.taskOrder {
+			if inDegree[task.name] > 0 {
+				cyclic = append(cyclic, task.name)
+			}
+		}
+		return nil, fmt.Errorf("task graph has a cycle: %s", strings.Join(cyclic, ", "))
+	}
+
+	return plan, nil
+}
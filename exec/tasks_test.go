@@ -0,0 +1,81 @@
+package exec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandBuilder_TaskPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*CommandBuilder)
+		plan     [][]string
+		errMatch string
+	}{
+		{
+			name: "independent tasks",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("b")
+				cb.Task("a")
+			},
+			plan: [][]string{{"a", "b"}},
+		},
+		{
+			name: "chain",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("release").After("test")
+				cb.Task("test").After("build")
+				cb.Task("build")
+			},
+			plan: [][]string{{"build"}, {"test"}, {"release"}},
+		},
+		{
+			name: "diamond",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("gen")
+				cb.Task("lint").After("gen")
+				cb.Task("build").After("gen")
+				cb.Task("release").After("lint", "build")
+			},
+			plan: [][]string{{"gen"}, {"build", "lint"}, {"release"}},
+		},
+		{
+			name: "unknown dependency",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("test").After("build")
+			},
+			errMatch: "unknown dependency build",
+		},
+		{
+			name: "cycle",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("a").After("c")
+				cb.Task("b").After("a")
+				cb.Task("c").After("b")
+				cb.Task("d")
+			},
+			errMatch: "cycle: a, b, c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := Commands()
+			test.setup(cb)
+			result := cb.RunTasks()
+			if test.errMatch != "" {
+				if result.Err() == nil || !strings.Contains(result.Err().Error(), test.errMatch) {
+					t.Fatalf("expecting error %q, got %v", test.errMatch, result.Err())
+				}
+				return
+			}
+			if err := result.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Plan(), test.plan) {
+				t.Errorf("unexpected plan: want %v, got %v", test.plan, result.Plan())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+//go:build !windows
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandBuilder_RunTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*CommandBuilder)
+		statuses map[string]TaskStatus
+		tree     string
+	}{
+		{
+			name: "all succeed",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("build", "echo build")
+				cb.Task("test", "echo test", "echo more tests").After("build")
+			},
+			statuses: map[string]TaskStatus{"build": TaskSucceeded, "test": TaskSucceeded},
+			tree:     "build [succeeded]\n└── test [succeeded]\n",
+		},
+		{
+			name: "failure skips dependents",
+			setup: func(cb *CommandBuilder) {
+				cb.Task("gen", "echo gen")
+				cb.Task("lint", "false").After("gen")
+				cb.Task("build", "echo build").After("gen")
+				cb.Task("release", "echo release").After("lint", "build")
+				cb.Task("publish", "echo publish").After("release")
+			},
+			statuses: map[string]TaskStatus{
+				"gen":     TaskSucceeded,
+				"lint":    TaskFailed,
+				"build":   TaskSucceeded,
+				"release": TaskSkipped,
+				"publish": TaskSkipped,
+			},
+			tree: "gen [succeeded]\n" +
+				"├── lint [failed]\n" +
+				"│   └── release [skipped]\n" +
+				"│       └── publish [skipped]\n" +
+				"└── build [succeeded]\n" +
+				"    └── release [skipped]\n" +
+				"        └── publish [skipped]\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := Commands()
+			test.setup(cb)
+			result := cb.RunTasks()
+			if err := result.Err(); err != nil {
+				t.Fatal(err)
+			}
+			for name, status := range test.statuses {
+				task := result.Task(name)
+				if task.Status() != status {
+					t.Errorf("task %s: expecting status %s, got %s (%v)", name, status, task.Status(), task.Err())
+				}
+			}
+			if result.Tree() != test.tree {
+				t.Errorf("unexpected tree:\n%s\nwant:\n%s", result.Tree(), test.tree)
+			}
+		})
+	}
+}
+
+func TestCommandBuilder_RunTasksParallel(t *testing.T) {
+	cb := Commands()
+	cb.Task("a", "sleep 0.5")
+	cb.Task("b", "sleep 0.5")
+	cb.Task("c", "echo done").After("a", "b")
+
+	start := time.Now()
+	result := cb.RunTasks()
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("independent tasks did not run in parallel: %s", elapsed)
+	}
+	if len(result.FailedTasks()) != 0 {
+		t.Fatalf("unexpected failed tasks: %v", result.FailedTasks())
+	}
+	if strings.TrimSpace(result.Task("c").Procs()[0].Result()) != "done" {
+		t.Errorf("unexpected result: %s", result.Task("c").Procs()[0].Result())
+	}
+}
+
+func TestCommandBuilder_RunTasksUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	output := filepath.Join(dir, "output.txt")
+	if err := os.WriteFile(input, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newBuilder := func() *CommandBuilder {
+		cb := Commands()
+		cb.Task("gen", fmt.Sprintf("cp %s %s", input, output)).Inputs(input).Outputs(output)
+		cb.Task("check", fmt.Sprintf("cat %s", output)).After("gen")
+		return cb
+	}
+
+	result := newBuilder().RunTasks()
+	if status := result.Task("gen").Status(); status != TaskSucceeded {
+		t.Fatalf("expecting gen to run, got status %s: %v", status, result.Task("gen").Err())
+	}
+
+	result = newBuilder().RunTasks()
+	if status := result.Task("gen").Status(); status != TaskUpToDate {
+		t.Fatalf("expecting gen to be up to date, got status %s", status)
+	}
+	if status := result.Task("check").Status(); status != TaskSucceeded {
+		t.Fatalf("expecting check to run after up-to-date dependency, got %s", status)
+	}
+}
@@ -0,0 +1,219 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vladimirvivien/gexe/fs"
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// errWatchOverflow is reported when the watcher lost changes (see fs.WatchOverflow)
+var errWatchOverflow = errors.New("event queue overflow, changes were lost")
+
+// DefaultWatchDebounce is the quiet period, after the last change, before a watch action runs
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// WatchBuilder runs a command, or a function, every time files matching a set of paths
+// or glob patterns change (see fs.Watcher). Changes are debounced, and a command that is
+// still running when new changes arrive is killed and restarted.
+type WatchBuilder struct {
+	ctx        context.Context
+	vars       *vars.Variables
+	watcher    *fs.Watcher
+	debounce   time.Duration
+	initialRun bool
+	stdout     io.Writer
+	stderr     io.Writer
+	procHook   func(*Proc)
+	onError    func(error)
+}
+
+// WatchWithContextVars creates a *WatchBuilder for the specified paths or glob patterns
+// using the specified context and session variables. Watching stops when ctx is done.
+func WatchWithContextVars(ctx context.Context, variables *vars.Variables, patterns ...string) *WatchBuilder {
+	return &WatchBuilder{
+		ctx:        ctx,
+		vars:       variables,
+		watcher:    fs.WatcherWithVars(variables, patterns...),
+		debounce:   DefaultWatchDebounce,
+		initialRun: true,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+	}
+}
+
+// Watch creates a *WatchBuilder for the specified paths or glob patterns
+func Watch(patterns ...string) *WatchBuilder {
+	return WatchWithContextVars(context.Background(), &vars.Variables{}, patterns...)
+}
+
+// Ignore adds patterns of paths whose changes are ignored (see fs.Watcher.Ignore)
+func (wb *WatchBuilder) Ignore(patterns ...string) *WatchBuilder {
+	wb.watcher.Ignore(patterns...)
+	return wb
+}
+
+// WithDebounce sets the quiet period, after the last change, before the action runs (default: 100ms)
+func (wb *WatchBuilder) WithDebounce(d time.Duration) *WatchBuilder {
+	wb.debounce = d
+	return wb
+}
+
+// WithInitialRun sets whether the action runs once when watching starts (default: true)
+func (wb *WatchBuilder) WithInitialRun(run bool) *WatchBuilder {
+	wb.initialRun = run
+	return wb
+}
+
+// WithStdout sets the standard output stream of commands (default: os.Stdout)
+func (wb *WatchBuilder) WithStdout(out io.Writer) *WatchBuilder {
+	wb.stdout = out
+	return wb
+}
+
+// WithStderr sets the standard error stream of commands (default: os.Stderr)
+func (wb *WatchBuilder) WithStderr(out io.Writer) *WatchBuilder {
+	wb.stderr = out
+	return wb
+}
+
+// WithProcHook sets a function that is called with each process before it starts
+func (wb *WatchBuilder) WithProcHook(hook func(*Proc)) *WatchBuilder {
+	wb.procHook = hook
+	return wb
+}
+
+// OnError sets a function that is called with the error of each failed action, such as a
+// command that exits with a non-zero status. By default, errors are written to the standard
+// error stream (see WatchBuilder.WithStderr). Actions stopped by a restart are not reported.
+// It is also called when the watcher loses changes because its event queue overflowed.
+func (wb *WatchBuilder) OnError(fn func(error)) *WatchBuilder {
+	wb.onError = fn
+	return wb
+}
+
+// Watcher returns the underlying *fs.Watcher
+func (wb *WatchBuilder) Watcher() *fs.Watcher {
+	return wb.watcher
+}
+
+// Run runs cmdStr, expanded with the session variables, each time changes are detected.
+// If the previous process is still running, it is killed (with its process group) before the
+// command is restarted. Failed commands are reported as set with WatchBuilder.OnError.
+// Run blocks until the context is done, which is not reported as an error.
+func (wb *WatchBuilder) Run(cmdStr string) error {
+	return wb.RunFunc(func(ctx context.Context, events []fs.WatchEvent) error {
+		proc := NewProcWithContextVars(ctx, cmdStr, wb.vars).SetProcGroup()
+		if err := proc.Err(); err != nil {
+			return fmt.Errorf("%s: %w", cmdStr, err)
+		}
+		proc.SetStdout(wb.stdout)
+		proc.SetStderr(wb.stderr)
+		if wb.procHook != nil {
+			wb.procHook(proc)
+		}
+		if err := proc.Start().Err(); err != nil {
+			return fmt.Errorf("%s: %w", cmdStr, err)
+		}
+		go func() {
+			select {
+			case <-ctx.Done():
+				proc.Signal(os.Kill)
+			case <-proc.Done():
+			}
+		}()
+		if err := proc.Wait().Err(); err != nil {
+			return fmt.Errorf("%s: %w", cmdStr, err)
+		}
+		return nil
+	})
+}
+
+// RunFunc calls fn, with the debounced changes, each time changes are detected. If the previous
+// call is still running, its context is canceled and it must return before fn is called again.
+// Errors returned by fn do not stop watching, they are reported as set with WatchBuilder.OnError.
+// When the watcher loses changes, which is reported the same way, fn is called with an
+// event of operation fs.WatchOverflow.
+// RunFunc blocks until the context is done.
+func (wb *WatchBuilder) RunFunc(fn func(ctx context.Context, events []fs.WatchEvent) error) error {
+	ctx := wb.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := wb.watcher.Start(ctx).Err(); err != nil {
+		return err
+	}
+	defer wb.watcher.Close()
+
+	var cancelRun context.CancelFunc
+	var runDone chan struct{}
+	stopRun := func() {
+		if cancelRun != nil {
+			cancelRun()
+			<-runDone
+		}
+	}
+	startRun := func(events []fs.WatchEvent) {
+		stopRun()
+		var runCtx context.Context
+		runCtx, cancelRun = context.WithCancel(ctx)
+		runDone = make(chan struct{})
+		go func(runCtx context.Context, done chan struct{}) {
+			defer close(done)
+			// errors of a canceled run are caused by a restart or by stopping
+			if err := fn(runCtx, events); err != nil && runCtx.Err() == nil {
+				wb.reportError(err)
+			}
+		}(runCtx, runDone)
+	}
+	defer stopRun()
+
+	if wb.initialRun {
+		startRun(nil)
+	}
+
+	var pending []fs.WatchEvent
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-wb.watcher.Events():
+			if !ok {
+				if err := wb.watcher.Err(); err != nil {
+					return err
+				}
+				if ctx.Err() == nil {
+					return errors.New("watch: watcher stopped")
+				}
+				return nil
+			}
+			// changes were lost: report it and run the action as if they were received
+			if event.Op&fs.WatchOverflow != 0 {
+				wb.reportError(errWatchOverflow)
+			}
+			pending = append(pending, event)
+			timer.Reset(wb.debounce)
+		case <-timer.C:
+			startRun(pending)
+			pending = nil
+		}
+	}
+}
+
+// reportError passes err to the error function, or writes it to the standard error stream
+func (wb *WatchBuilder) reportError(err error) {
+	if wb.onError != nil {
+		wb.onError(err)
+		return
+	}
+	if wb.stderr != nil {
+		fmt.Fprintf(wb.stderr, "watch: %s\n", err)
+	}
+}
@@ -0,0 +1,164 @@
+//go:build !windows
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/gexe/fs"
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchRun(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the command prints a start marker then stays running until killed
+	var out, errOut syncBuffer
+	done := make(chan error)
+	go func() {
+		done <- WatchWithContextVars(ctx, vars.New(), dir).
+			WithDebounce(50 * time.Millisecond).
+			WithStdout(&out).
+			WithStderr(&errOut).
+			Run(`/bin/sh -c "echo started; sleep 30; echo finished"`)
+	}()
+
+	waitFor := func(count int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for strings.Count(out.String(), "started") < count {
+			if time.Now().After(deadline) {
+				t.Fatalf("expecting %d starts, got output: %q", count, out.String())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	waitFor(1)
+	// several changes within the debounce period restart the command once
+	for i := range 3 {
+		os.WriteFile(filepath.Join(dir, "file.txt"), []byte{byte(i)}, 0644)
+	}
+	waitFor(2)
+	time.Sleep(200 * time.Millisecond)
+	if starts := strings.Count(out.String(), "started"); starts != 2 {
+		t.Errorf("expecting 2 starts, got %d", starts)
+	}
+	// the command killed by the restart is not reported as failed
+	if errOut.String() != "" {
+		t.Errorf("unexpected errors: %q", errOut.String())
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop")
+	}
+	if strings.Contains(out.String(), "finished") {
+		t.Error("expecting running command to be killed")
+	}
+}
+
+func TestWatchRunFunc(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	batches := make(chan []fs.WatchEvent, 4)
+	go WatchWithContextVars(ctx, vars.New(), filepath.Join(dir, "*.go")).
+		Ignore("skip_*").
+		WithInitialRun(false).
+		WithDebounce(50 * time.Millisecond).
+		RunFunc(func(ctx context.Context, events []fs.WatchEvent) error {
+			batches <- events
+			return nil
+		})
+
+	time.Sleep(100 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "skip_me.go"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), nil, 0644)
+
+	select {
+	case events := <-batches:
+		for _, event := range events {
+			if filepath.Base(event.Path) != "main.go" {
+				t.Errorf("unexpected event: %+v", event)
+			}
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for changes")
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// failed commands are written to stderr by default
+	var errOut syncBuffer
+	go WatchWithContextVars(ctx, vars.New(), dir).
+		WithStderr(&errOut).
+		Run(`/bin/sh -c "exit 3"`)
+
+	// errors returned by functions are passed to the error function
+	errs := make(chan error, 1)
+	go WatchWithContextVars(ctx, vars.New(), dir).
+		OnError(func(err error) { errs <- err }).
+		RunFunc(func(ctx context.Context, events []fs.WatchEvent) error {
+			return errors.New("build failed")
+		})
+
+	select {
+	case err := <-errs:
+		if err.Error() != "build failed" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for error")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(errOut.String(), "exit status 3") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expecting reported command failure, got %q", errOut.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !strings.HasPrefix(errOut.String(), `watch: /bin/sh -c "exit 3": `) {
+		t.Errorf("unexpected error output: %q", errOut.String())
+	}
+}
@@ -72,3 +72,9 @@ func (e *Session) FileAppend(path string, args ...interface{}) *fs.FileWriter {
 	path = applyFmt(path, args...)
 	return fs.AppendWithContextVars(context.Background(), path, e.vars)
 }
+
+// Targets returns a *fs.Targets used to declare input and output files
+// and check whether the outputs are up to date.
+func (e *Session) Targets() *fs.Targets {
+	return fs.TargetsWithVars(e.vars)
+}
@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingWriter is an io.WriteCloser that appends to a file and rotates it when it
+// reaches a maximum size. Rotated files are renamed with a numeric suffix (i.e. app.log.1)
+// where the highest suffix is the oldest. It is safe for concurrent use.
+type RotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingWriter creates a *RotatingWriter for path that rotates the file once
+// it exceeds maxSize bytes, keeping at most maxBackups rotated files.
+// A maxSize <= 0 disables rotation.
+func NewRotatingWriter(path string, maxSize int64, maxBackups int) (*RotatingWriter, error) {
+	w := &RotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Path returns the path of the current file
+func (w *RotatingWriter) Path() string {
+	return w.path
+}
+
+// Write appends data to the current file, rotating it first if the write
+// would exceed the maximum size.
+func (w *RotatingWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(data)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(data)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens (or creates) the file for appending
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts existing backups, renames the current file as the first backup,
+// and opens a new file.
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+
+	for i := w.maxBackups - 1; i > 0; i-- {
+		older := fmt.Sprintf("%s.%d", w.path, i)
+		if !Path(older).Exists() {
+			continue
+		}
+		if err := os.Rename(older, fmt.Sprintf("%s.%d", w.path, i+1)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
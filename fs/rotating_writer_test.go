@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSize    int64
+		maxBackups int
+		writes     []string
+		files      map[string]string
+	}{
+		{
+			name:       "no rotation",
+			maxSize:    0,
+			maxBackups: 2,
+			writes:     []string{"aaaa", "bbbb"},
+			files:      map[string]string{"app.log": "aaaabbbb"},
+		},
+		{
+			name:       "rotate with backups",
+			maxSize:    8,
+			maxBackups: 2,
+			writes:     []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff"},
+			files: map[string]string{
+				"app.log":   "eeeeffff",
+				"app.log.1": "ccccdddd",
+				"app.log.2": "aaaabbbb",
+			},
+		},
+		{
+			name:       "drop oldest backup",
+			maxSize:    4,
+			maxBackups: 1,
+			writes:     []string{"aaaa", "bbbb", "cccc"},
+			files: map[string]string{
+				"app.log":   "cccc",
+				"app.log.1": "bbbb",
+			},
+		},
+		{
+			name:       "rotate without backups",
+			maxSize:    4,
+			maxBackups: 0,
+			writes:     []string{"aaaa", "bbbb"},
+			files:      map[string]string{"app.log": "bbbb"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := NewRotatingWriter(filepath.Join(dir, "app.log"), test.maxSize, test.maxBackups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range test.writes {
+				if _, err := fmt.Fprint(w, data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(test.files) {
+				t.Errorf("expecting %d files, got %d", len(test.files), len(entries))
+			}
+			for name, content := range test.files {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.TrimSpace(string(data)) != content {
+					t.Errorf("file %s: expecting %q, got %q", name, content, string(data))
+				}
+			}
+		})
+	}
+}
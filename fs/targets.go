@@ -0,0 +1,260 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// stateMu serializes access to target state files within the program
+var stateMu sync.Mutex
+
+// Targets declares input files (or globs) used to produce output files. It is used
+// to determine, make-style, whether the outputs are up to date with their inputs.
+// By default, outputs are up to date when they are all newer than every input.
+// Use Targets.WithHashState to compare input content hashes instead.
+type Targets struct {
+	err       error
+	inputs    []string
+	outputs   []string
+	stateFile string
+	vars      *vars.Variables
+}
+
+// NewTargets creates a new Targets value
+func NewTargets() *Targets {
+	return &Targets{vars: &vars.Variables{}}
+}
+
+// TargetsWithVars creates a new Targets value that expands paths with the session variables
+func TargetsWithVars(variables *vars.Variables) *Targets {
+	t := NewTargets()
+	t.vars = variables
+	return t
+}
+
+// Inputs adds input file paths or glob patterns (see filepath.Match)
+func (t *Targets) Inputs(paths ...string) *Targets {
+	for _, path := range paths {
+		t.inputs = append(t.inputs, t.vars.Eval(path))
+	}
+	return t
+}
+
+// Outputs adds output file paths
+func (t *Targets) Outputs(paths ...string) *Targets {
+	for _, path := range paths {
+		t.outputs = append(t.outputs, t.vars.Eval(path))
+	}
+	return t
+}
+
+// WithHashState switches the up-to-date check to compare the SHA-256 hash of input files
+// against the hashes stored, by Targets.Record, in the specified state file.
+func (t *Targets) WithHashState(stateFile string) *Targets {
+	t.stateFile = t.vars.Eval(stateFile)
+	return t
+}
+
+// Err returns the last error encountered while checking targets
+func (t *Targets) Err() error {
+	return t.err
+}
+
+// UpToDate returns true if all outputs exist and are up to date with respect to the inputs.
+// Targets with no outputs are never up to date. Any error causes it to return false.
+func (t *Targets) UpToDate() bool {
+	t.err = nil
+	if len(t.outputs) == 0 {
+		return false
+	}
+
+	var oldest time.Time
+	for i, output := range t.outputs {
+		info := Path(output).Info()
+		if info.Err() != nil {
+			if !os.IsNotExist(info.Err()) {
+				t.err = info.Err()
+			}
+			return false
+		}
+		if i == 0 || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+		}
+	}
+
+	inputs, err := t.expandInputs()
+	if err != nil {
+		t.err = err
+		return false
+	}
+
+	if t.stateFile != "" {
+		return t.hashesMatch(inputs)
+	}
+
+	for _, input := range inputs {
+		info := Path(input).Info()
+		if info.Err() != nil {
+			t.err = info.Err()
+			return false
+		}
+		if !info.ModTime().Before(oldest) {
+			return false
+		}
+	}
+	return true
+}
+
+// Record stores the current input hashes in the state file when hash state is enabled.
+// It should be called after the outputs have been successfully produced.
+func (t *Targets) Record() error {
+	if t.stateFile == "" {
+		return nil
+	}
+
+	inputs, err := t.expandInputs()
+	if err != nil {
+		return err
+	}
+	hashes, err := hashFiles(inputs)
+	if err != nil {
+		return err
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	state, err := readTargetState(t.stateFile)
+	if err != nil {
+		return err
+	}
+	state[t.key()] = hashes
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(t.stateFile); dir != "" {
+		if err := Path(dir).MkDir(0755).Err(); err != nil {
+			return err
+		}
+	}
+	return Write(t.stateFile).Bytes(data).Err()
+}
+
+// hashesMatch compares current input hashes with those stored in the state file
+func (t *Targets) hashesMatch(inputs []string) bool {
+	hashes, err := hashFiles(inputs)
+	if err != nil {
+		t.err = err
+		return false
+	}
+
+	stateMu.Lock()
+	state, err := readTargetState(t.stateFile)
+	stateMu.Unlock()
+	if err != nil {
+		t.err = err
+		return false
+	}
+
+	recorded, ok := state[t.key()]
+	if !ok || len(recorded) != len(hashes) {
+		return false
+	}
+	for path, hash := range hashes {
+		if recorded[path] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// key identifies the targets in the state file using their outputs
+func (t *Targets) key() string {
+	outputs := append([]string{}, t.outputs...)
+	sort.Strings(outputs)
+	return strings.Join(outputs, ",")
+}
+
+// expandInputs expands input globs into a sorted list of file paths.
+// Non-glob inputs must exist.
+func (t *Targets) expandInputs() ([]string, error) {
+	var paths []string
+	for _, input := range t.inputs {
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("targets: input %s: %w", input, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(input, "*?[") {
+			return nil, fmt.Errorf("targets: input %s: %w", input, os.ErrNotExist)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// hashFiles returns the SHA-256 hash of each regular file in paths
+func hashFiles(paths []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, path := range paths {
+		info := Path(path).Info()
+		if info.Err() != nil {
+			return nil, info.Err()
+		}
+		if info.IsDir() {
+			continue
+		}
+		hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		hashes[path] = hash
+	}
+	return hashes, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readTargetState reads the state file, returning an empty state if it does not exist
+func readTargetState(stateFile string) (map[string]map[string]string, error) {
+	state := make(map[string]map[string]string)
+	if !Path(stateFile).Exists() {
+		return state, nil
+	}
+	reader := Read(stateFile)
+	data := reader.Bytes()
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("targets: state file %s: %w", stateFile, err)
+	}
+	return state, nil
+}
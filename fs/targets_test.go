@@ -0,0 +1,113 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTargetsUpToDate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string) *Targets
+		upToDate bool
+		hasErr   bool
+	}{
+		{
+			name: "no outputs",
+			setup: func(t *testing.T, dir string) *Targets {
+				writeFile(t, filepath.Join(dir, "in.txt"), "in", now)
+				return NewTargets().Inputs(filepath.Join(dir, "in.txt"))
+			},
+		},
+		{
+			name: "missing output",
+			setup: func(t *testing.T, dir string) *Targets {
+				writeFile(t, filepath.Join(dir, "in.txt"), "in", now)
+				return NewTargets().Inputs(filepath.Join(dir, "in.txt")).Outputs(filepath.Join(dir, "out.txt"))
+			},
+		},
+		{
+			name: "output newer than inputs",
+			setup: func(t *testing.T, dir string) *Targets {
+				writeFile(t, filepath.Join(dir, "a.in"), "a", now.Add(-2*time.Hour))
+				writeFile(t, filepath.Join(dir, "b.in"), "b", now.Add(-time.Hour))
+				writeFile(t, filepath.Join(dir, "out.txt"), "out", now)
+				return NewTargets().Inputs(filepath.Join(dir, "*.in")).Outputs(filepath.Join(dir, "out.txt"))
+			},
+			upToDate: true,
+		},
+		{
+			name: "input newer than output",
+			setup: func(t *testing.T, dir string) *Targets {
+				writeFile(t, filepath.Join(dir, "a.in"), "a", now.Add(-2*time.Hour))
+				writeFile(t, filepath.Join(dir, "b.in"), "b", now)
+				writeFile(t, filepath.Join(dir, "out.txt"), "out", now.Add(-time.Hour))
+				return NewTargets().Inputs(filepath.Join(dir, "*.in")).Outputs(filepath.Join(dir, "out.txt"))
+			},
+		},
+		{
+			name: "missing input",
+			setup: func(t *testing.T, dir string) *Targets {
+				writeFile(t, filepath.Join(dir, "out.txt"), "out", now)
+				return NewTargets().Inputs(filepath.Join(dir, "in.txt")).Outputs(filepath.Join(dir, "out.txt"))
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targets := test.setup(t, t.TempDir())
+			if targets.UpToDate() != test.upToDate {
+				t.Errorf("expecting up to date %t", test.upToDate)
+			}
+			if (targets.Err() != nil) != test.hasErr {
+				t.Errorf("unexpected error state: %v", targets.Err())
+			}
+		})
+	}
+}
+
+func TestTargetsHashState(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	input := filepath.Join(dir, "in.txt")
+	output := filepath.Join(dir, "out.txt")
+	state := filepath.Join(dir, "state", "targets.json")
+
+	// output is older than input, but hash mode ignores modification times
+	writeFile(t, input, "hello", now)
+	writeFile(t, output, "out", now.Add(-time.Hour))
+
+	newTargets := func() *Targets {
+		return NewTargets().Inputs(input).Outputs(output).WithHashState(state)
+	}
+
+	if newTargets().UpToDate() {
+		t.Fatal("targets should not be up to date before recording")
+	}
+	if err := newTargets().Record(); err != nil {
+		t.Fatal(err)
+	}
+	if !newTargets().UpToDate() {
+		t.Fatal("targets should be up to date after recording")
+	}
+
+	writeFile(t, input, "hello world", now.Add(-2*time.Hour))
+	if newTargets().UpToDate() {
+		t.Fatal("targets should not be up to date after input content changed")
+	}
+}
+
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := Write(path).String(content).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,314 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/gexe/vars"
+)
+
+// DefaultPollInterval is the interval used to poll for changes on platforms without file notifications
+const DefaultPollInterval = 500 * time.Millisecond
+
+// WatchOp is a bitmask of file system operations reported by a Watcher
+type WatchOp uint32
+
+const (
+	WatchCreate WatchOp = 1 << iota
+	WatchWrite
+	WatchRemove
+	WatchRename
+	WatchChmod
+	// WatchOverflow reports that the event queue overflowed and that changes were lost.
+	// Its event has no path.
+	WatchOverflow
+)
+
+// String returns the operations as a list separated with '|' (i.e. create|write)
+func (op WatchOp) String() string {
+	var ops []string
+	for _, o := range []struct {
+		op   WatchOp
+		name string
+	}{{WatchCreate, "create"}, {WatchWrite, "write"}, {WatchRemove, "remove"}, {WatchRename, "rename"}, {WatchChmod, "chmod"}, {WatchOverflow, "overflow"}} {
+		if op&o.op != 0 {
+			ops = append(ops, o.name)
+		}
+	}
+	return strings.Join(ops, "|")
+}
+
+// WatchEvent is a change to a watched path, or an overflow of the event queue (see WatchOverflow)
+type WatchEvent struct {
+	Path string
+	Op   WatchOp
+	Time time.Time
+}
+
+// watchRoot is a directory watched by a Watcher
+type watchRoot struct {
+	dir       string
+	recursive bool
+}
+
+// Watcher reports changes to files matching a set of paths or glob patterns. Directories are
+// watched recursively. Patterns use filepath.Match syntax where a "**" path segment matches
+// any number of directories (i.e. src/**/*.go). On Linux, changes are reported by inotify,
+// elsewhere they are detected by polling file modification times.
+type Watcher struct {
+	mu       sync.Mutex
+	vars     *vars.Variables
+	patterns []string
+	ignores  []string
+	interval time.Duration
+	events   chan WatchEvent
+	err      error
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewWatcher creates a *Watcher for the specified paths or glob patterns
+func NewWatcher(patterns ...string) *Watcher {
+	return WatcherWithVars(&vars.Variables{}, patterns...)
+}
+
+// WatcherWithVars creates a *Watcher for the specified paths or glob patterns,
+// which are expanded with the session variables.
+func WatcherWithVars(variables *vars.Variables, patterns ...string) *Watcher {
+	w := &Watcher{vars: variables, interval: DefaultPollInterval, events: make(chan WatchEvent, 256)}
+	for _, pattern := range patterns {
+		w.patterns = append(w.patterns, w.normalize(pattern))
+	}
+	return w
+}
+
+// Ignore adds patterns of paths that are not reported. A pattern without a path separator
+// is matched against each element of a path (i.e. ".git", "*.tmp", or "node_modules"),
+// otherwise it is matched against the whole path.
+func (w *Watcher) Ignore(patterns ...string) *Watcher {
+	for _, pattern := range patterns {
+		w.ignores = append(w.ignores, filepath.ToSlash(w.vars.Eval(pattern)))
+	}
+	return w
+}
+
+// WithPollInterval sets the polling interval used on platforms without file notifications
+func (w *Watcher) WithPollInterval(interval time.Duration) *Watcher {
+	if interval > 0 {
+		w.interval = interval
+	}
+	return w
+}
+
+// Start starts watching for changes until ctx is done or Watcher.Close is called.
+// Paths are being watched when Start returns. Check Watcher.Err for errors.
+func (w *Watcher) Start(ctx context.Context) *Watcher {
+	if len(w.patterns) == 0 {
+		w.setErr(errors.New("watch: no paths to watch"))
+		close(w.events)
+		return w
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	run, err := w.start(ctx, w.roots())
+	if err != nil {
+		cancel()
+		w.setErr(err)
+		close(w.events)
+		return w
+	}
+
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		defer close(w.events)
+		if err := run(); err != nil && ctx.Err() == nil {
+			w.setErr(err)
+		}
+	}()
+	return w
+}
+
+// Events returns the channel of changes. It is closed when the watcher stops.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Err returns the error that stopped the watcher, if any
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops the watcher and waits for it to release its resources
+func (w *Watcher) Close() error {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+	return w.Err()
+}
+
+// Match returns true if path matches a watched pattern and no ignore pattern
+func (w *Watcher) Match(path string) bool {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if w.ignored(path) {
+		return false
+	}
+	for _, pattern := range w.patterns {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+}
+
+// emit sends event if its path matches, it returns false if ctx is done
+func (w *Watcher) emit(ctx context.Context, path string, op WatchOp) bool {
+	if !w.Match(path) {
+		return true
+	}
+	select {
+	case w.events <- WatchEvent{Path: path, Op: op, Time: time.Now()}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// emitOverflow sends an overflow event, it returns false if ctx is done
+func (w *Watcher) emitOverflow(ctx context.Context) bool {
+	select {
+	case w.events <- WatchEvent{Op: WatchOverflow, Time: time.Now()}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ignored returns true if path matches an ignore pattern
+func (w *Watcher) ignored(path string) bool {
+	for _, pattern := range w.ignores {
+		if !strings.Contains(pattern, "/") {
+			for _, elem := range strings.Split(path, "/") {
+				if ok, _ := filepath.Match(pattern, elem); ok {
+					return true
+				}
+			}
+			continue
+		}
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize expands and cleans pattern; a directory is converted to a pattern matching its content
+func (w *Watcher) normalize(pattern string) string {
+	pattern = filepath.Clean(w.vars.Eval(pattern))
+	if !hasMeta(pattern) {
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "**")
+		}
+	}
+	return filepath.ToSlash(pattern)
+}
+
+// roots returns the directories to watch for the patterns: the
+// directory part of each pattern that precedes its first glob character.
+func (w *Watcher) roots() []watchRoot {
+	var roots []watchRoot
+	seen := make(map[string]int)
+	for _, pattern := range w.patterns {
+		segments := strings.Split(pattern, "/")
+		static := 0
+		for static < len(segments)-1 && !hasMeta(segments[static]) {
+			static++
+		}
+		dir := strings.Join(segments[:static], "/")
+		switch {
+		case dir == "" && strings.HasPrefix(pattern, "/"):
+			dir = "/"
+		case dir == "":
+			dir = "."
+		}
+		rest := segments[static:]
+		root := watchRoot{dir: filepath.FromSlash(dir), recursive: len(rest) > 1 || strings.Contains(pattern, "**")}
+
+		if i, ok := seen[root.dir]; ok {
+			roots[i].recursive = roots[i].recursive || root.recursive
+			continue
+		}
+		seen[root.dir] = len(roots)
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// walkRoot calls fn for root.dir and, if recursive, each of its subdirectories that is not ignored
+func (w *Watcher) walkRoot(root watchRoot, fn func(dir string) error) error {
+	if !root.recursive {
+		return fn(root.dir)
+	}
+	return filepath.WalkDir(root.dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			// ignore directories removed while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root.dir && w.ignored(filepath.ToSlash(path)) {
+			return filepath.SkipDir
+		}
+		return fn(path)
+	})
+}
+
+// matchPath reports whether the slash-separated path matches pattern, where
+// a "**" segment matches zero or more path segments.
+func matchPath(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
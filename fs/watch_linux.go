@@ -0,0 +1,135 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB | syscall.IN_DELETE_SELF
+
+// start adds inotify watches for the roots and returns a function that reads events until ctx is done
+func (w *Watcher) start(ctx context.Context, roots []watchRoot) (func() error, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("watch: inotify: %w", err)
+	}
+	// a non-blocking fd uses the runtime poller, so Close unblocks a pending Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	dirs := make(map[int32]watchRoot)
+	addDirs := func(root watchRoot) error {
+		return w.walkRoot(root, func(dir string) error {
+			wd, err := syscall.InotifyAddWatch(fd, dir, inotifyMask)
+			if err != nil {
+				if errors.Is(err, syscall.ENOENT) {
+					return nil
+				}
+				return fmt.Errorf("watch: %s: %w", dir, err)
+			}
+			dirs[int32(wd)] = watchRoot{dir: dir, recursive: root.recursive}
+			return nil
+		})
+	}
+	for _, root := range roots {
+		if err := addDirs(root); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	run := func() error {
+		stop := context.AfterFunc(ctx, func() { file.Close() })
+		defer stop()
+		defer file.Close()
+
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("watch: inotify: %w", err)
+			}
+
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+				if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+					if !w.emitOverflow(ctx) {
+						return nil
+					}
+					continue
+				}
+
+				parent, ok := dirs[event.Wd]
+				if !ok {
+					continue
+				}
+				if event.Mask&syscall.IN_IGNORED != 0 {
+					delete(dirs, event.Wd)
+					continue
+				}
+
+				path := parent.dir
+				if name := cString(nameBytes); name != "" {
+					path = filepath.Join(parent.dir, name)
+				}
+
+				// watch directories created under a recursive root
+				isDir := event.Mask&syscall.IN_ISDIR != 0
+				if isDir && parent.recursive && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && !w.ignored(filepath.ToSlash(path)) {
+					if err := addDirs(watchRoot{dir: path, recursive: true}); err != nil {
+						return err
+					}
+				}
+
+				if op := inotifyOp(event.Mask); op != 0 {
+					if !w.emit(ctx, path, op) {
+						return nil
+					}
+				}
+			}
+		}
+	}
+	return run, nil
+}
+
+// inotifyOp converts an inotify event mask to WatchOp
+func inotifyOp(mask uint32) WatchOp {
+	var op WatchOp
+	if mask&syscall.IN_CREATE != 0 {
+		op |= WatchCreate
+	}
+	if mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE) != 0 {
+		op |= WatchWrite
+	}
+	if mask&(syscall.IN_DELETE|syscall.IN_DELETE_SELF) != 0 {
+		op |= WatchRemove
+	}
+	if mask&(syscall.IN_MOVED_FROM|syscall.IN_MOVED_TO) != 0 {
+		op |= WatchRename
+	}
+	if mask&syscall.IN_ATTRIB != 0 {
+		op |= WatchChmod
+	}
+	return op
+}
+
+// cString returns the NUL-terminated string in data
+func cString(data []byte) string {
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcherOverflow(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	w := NewWatcher(dir).Start(ctx)
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// fill the inotify queue while events are not read, each file write queues 3 events
+	data, err := os.ReadFile("/proc/sys/fs/inotify/max_queued_events")
+	if err != nil {
+		t.Skip(err)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || limit > 100_000 {
+		t.Skipf("inotify queue too large: %s", data)
+	}
+	for i := 0; i < limit/2; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for {
+		select {
+		case event := <-w.Events():
+			if event.Op&WatchOverflow != 0 {
+				if event.Path != "" || event.Op.String() != "overflow" {
+					t.Errorf("unexpected overflow event: %+v", event)
+				}
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for overflow event")
+		}
+	}
+}
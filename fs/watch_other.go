@@ -0,0 +1,92 @@
+//go:build !linux
+
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileState is the state of a file used to detect changes by polling
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+}
+
+// start takes a snapshot of the roots and returns a function that polls for changes until ctx is done
+func (w *Watcher) start(ctx context.Context, roots []watchRoot) (func() error, error) {
+	snapshot, err := w.snapshot(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	run := func() error {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			current, err := w.snapshot(roots)
+			if err != nil {
+				return err
+			}
+			for path, state := range current {
+				prev, ok := snapshot[path]
+				var op WatchOp
+				switch {
+				case !ok:
+					op = WatchCreate
+				case state.mode != prev.mode:
+					op = WatchChmod
+				case !state.mode.IsDir() && (!state.modTime.Equal(prev.modTime) || state.size != prev.size):
+					op = WatchWrite
+				}
+				if op != 0 && !w.emit(ctx, path, op) {
+					return nil
+				}
+			}
+			for path := range snapshot {
+				if _, ok := current[path]; !ok && !w.emit(ctx, path, WatchRemove) {
+					return nil
+				}
+			}
+			snapshot = current
+		}
+	}
+	return run, nil
+}
+
+// snapshot returns the state of the entries in the watched directories
+func (w *Watcher) snapshot(roots []watchRoot) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	for _, root := range roots {
+		err := w.walkRoot(root, func(dir string) error {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			for _, entry := range entries {
+				info, err := entry.Info()
+				if err != nil {
+					continue
+				}
+				states[filepath.Join(dir, entry.Name())] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "*.go", path: "main.go", match: true},
+		{pattern: "*.go", path: "pkg/main.go"},
+		{pattern: "src/**", path: "src/a/b/c.txt", match: true},
+		{pattern: "src/**/*.go", path: "src/main.go", match: true},
+		{pattern: "src/**/*.go", path: "src/a/b/main.go", match: true},
+		{pattern: "src/**/*.go", path: "src/a/b/main.txt"},
+		{pattern: "src/*/x", path: "src/a/b/x"},
+		{pattern: "/tmp/**", path: "/tmp/a", match: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			if match := matchPath(test.pattern, test.path); match != test.match {
+				t.Errorf("expecting match %t, got %t", test.match, match)
+			}
+		})
+	}
+}
+
+func TestWatcherMatchAndRoots(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWatcher(dir, "src/*.go", "docs/**/*.md").Ignore(".git", "*.tmp", "src/gen_*.go")
+
+	tests := []struct {
+		path  string
+		match bool
+	}{
+		{path: filepath.Join(dir, "a.txt"), match: true},
+		{path: filepath.Join(dir, "sub", "b.txt"), match: true},
+		{path: filepath.Join(dir, ".git", "HEAD")},
+		{path: filepath.Join(dir, "c.tmp")},
+		{path: "src/main.go", match: true},
+		{path: "src/gen_api.go"},
+		{path: "docs/a/b/readme.md", match: true},
+		{path: "docs/readme.txt"},
+	}
+	for _, test := range tests {
+		if match := w.Match(test.path); match != test.match {
+			t.Errorf("%s: expecting match %t, got %t", test.path, test.match, match)
+		}
+	}
+
+	expected := []watchRoot{{dir: dir, recursive: true}, {dir: "src"}, {dir: "docs", recursive: true}}
+	if roots := w.roots(); !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expecting roots %+v, got %+v", expected, roots)
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	w := NewWatcher(filepath.Join(dir, "**", "*.txt")).Ignore("*.tmp").WithPollInterval(50 * time.Millisecond).Start(ctx)
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	expectEvent := func(path string, op WatchOp) {
+		t.Helper()
+		for {
+			select {
+			case event := <-w.Events():
+				if event.Path == path && event.Op&op != 0 {
+					return
+				}
+				if filepath.Ext(event.Path) != ".txt" {
+					t.Fatalf("unexpected event for %s", event.Path)
+				}
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for %s on %s", op, path)
+			}
+		}
+	}
+
+	file := filepath.Join(dir, "sub", "a.txt")
+	os.WriteFile(filepath.Join(dir, "ignored.tmp"), []byte("x"), 0644)
+	if err := os.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(file, WatchCreate|WatchWrite)
+
+	// directories created after the watcher started are watched too
+	newFile := filepath.Join(dir, "new", "b.txt")
+	if err := os.Mkdir(filepath.Dir(newFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(newFile, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(newFile, WatchCreate|WatchWrite)
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(file, WatchRemove)
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range w.Events() {
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"time"
 
 	"github.com/vladimirvivien/gexe/exec"
 	"github.com/vladimirvivien/gexe/fs"
@@ -11,6 +12,7 @@ import (
 	"github.com/vladimirvivien/gexe/prog"
 	"github.com/vladimirvivien/gexe/str"
 	"github.com/vladimirvivien/gexe/vars"
+	"github.com/vladimirvivien/gexe/wait"
 )
 
 // Variables returns variable map for DefaultEcho session
@@ -55,6 +57,16 @@ func Val(name string) string {
 	return DefaultSession.Val(name)
 }
 
+// Alias declares name as an alias for cmdStr in the default session
+func Alias(name, cmdStr string) *Session {
+	return DefaultSession.Alias(name, cmdStr)
+}
+
+// Define declares a macro, with positional parameters, in the default session
+func Define(name, body string) *Session {
+	return DefaultSession.Define(name, body)
+}
+
 // Eval returns the string str with its content expanded
 // with variable values i.e. Eval("I am $HOME") returns
 // "I am </user/home/path>"
@@ -74,6 +86,12 @@ func NewProc(cmdStr string, args ...interface{}) *exec.Proc {
 	return DefaultSession.NewProcWithContext(context.Background(), cmdStr, args...)
 }
 
+// NewProcArgs setups a new process from an explicit command name and argument list without starting it.
+// The name and args are not parsed nor expanded, unless selected with Proc.ExpandArgs.
+func NewProcArgs(name string, args ...string) *exec.Proc {
+	return DefaultSession.NewProcArgs(name, args...)
+}
+
 // StartProcWith executes the command in cmdStr with the specified contex and returns immediately
 // without waiting. Information about the running process is stored in *exec.Proc.
 func StartProcWithContext(ctx context.Context, cmdStr string, args ...interface{}) *exec.Proc {
@@ -109,6 +127,12 @@ func Run(cmdStr string, args ...interface{}) string {
 	return DefaultSession.Run(cmdStr, args...)
 }
 
+// RunArgs executes the command name with an explicit argument list and returns the result as a string.
+// The name and args are not parsed nor expanded (see Session.RunArgs).
+func RunArgs(name string, args ...string) string {
+	return DefaultSession.RunArgs(name, args...)
+}
+
 // Runout executes command cmdStr and prints out the result
 func Runout(cmdStr string, args ...interface{}) {
 	DefaultSession.Runout(cmdStr, args...)
@@ -143,12 +167,85 @@ func RunConcur(cmdStrs ...string) *exec.CommandResult {
 	return DefaultSession.RunConcur(cmdStrs...)
 }
 
+// ForEach returns a *exec.ForEachBuilder that runs cmdStr for each item in items.
+// Each item is placed, as-is, where variable ${item} is referenced in cmdStr.
+func ForEach(items []string, cmdStr string) *exec.ForEachBuilder {
+	return DefaultSession.ForEach(items, cmdStr)
+}
+
+// ForEachReader returns a *exec.ForEachBuilder that runs cmdStr for each
+// non-empty line read from reader.
+func ForEachReader(reader io.Reader, cmdStr string) *exec.ForEachBuilder {
+	return DefaultSession.ForEachReader(reader, cmdStr)
+}
+
+// RunScript runs the script file at path, line by line, and returns a *exec.ScriptResult
+// with the result of each step.
+func RunScript(path string) *exec.ScriptResult {
+	return DefaultSession.RunScript(path)
+}
+
+// RunScriptString runs the script source src, line by line, and returns a *exec.ScriptResult
+// with the result of each step.
+func RunScriptString(src string) *exec.ScriptResult {
+	return DefaultSession.RunScriptString(src)
+}
+
+// Checkpoints returns a *exec.CheckpointBuilder that runs named steps and records the
+// completion of each step under stateDir, so that a failed run can be resumed.
+func Checkpoints(stateDir string) *exec.CheckpointBuilder {
+	return DefaultSession.Checkpoints(stateDir)
+}
+
+// Bench runs each command opts.Runs times and returns a *exec.BenchReport comparing them
+func Bench(opts exec.BenchOptions, cmdStrs ...string) *exec.BenchReport {
+	return DefaultSession.Bench(opts, cmdStrs...)
+}
+
+// Watch returns a *exec.WatchBuilder that reruns a command, or function, when files
+// matching the paths or glob patterns change.
+func Watch(patterns ...string) *exec.WatchBuilder {
+	return DefaultSession.Watch(patterns...)
+}
+
+// StartDetached starts cmdStr as a process fully detached from the running program.
+// The process survives the program's exit.
+func StartDetached(cmdStr string, opts exec.DetachOptions) *exec.DetachedProc {
+	return DefaultSession.StartDetached(cmdStr, opts)
+}
+
+// Status reports whether the process recorded in pidFile is still running
+func Status(pidFile string) *exec.PidStatus {
+	return DefaultSession.Status(pidFile)
+}
+
+// Stop terminates the process recorded in pidFile and removes the pid file
+func Stop(pidFile string, grace time.Duration) error {
+	return DefaultSession.Stop(pidFile, grace)
+}
+
+// NewSupervisor returns a *exec.Supervisor to keep a set of services running with restart policies.
+func NewSupervisor() *exec.Supervisor {
+	return DefaultSession.NewSupervisor()
+}
+
 // Pipe executes each command, in cmdStrs, by piping the result
 // of the previous command as input to the next command until done.
 func Pipe(cmdStrs ...string) *exec.PipedCommandResult {
 	return DefaultSession.Pipe(cmdStrs...)
 }
 
+// ForwardSignals relays the specified signals, received by the running program,
+// to all running processes started with the default session.
+func ForwardSignals(sigs ...os.Signal) *SignalForwarder {
+	return DefaultSession.ForwardSignals(sigs...)
+}
+
+// WaitFor waits, concurrently, until all conditions are satisfied or time out.
+func WaitFor(ctx context.Context, conditions ...*wait.Condition) error {
+	return DefaultSession.WaitFor(ctx, conditions...)
+}
+
 // PathExists returns true if specified path exists.
 // Any error will cause it to return false.
 func PathExists(path string, args ...interface{}) bool {
@@ -175,6 +272,12 @@ func RmPath(path string, args ...interface{}) *fs.FSInfo {
 	return DefaultSession.RmPath(path, args...)
 }
 
+// Targets returns a *fs.Targets used to declare input and output files
+// and check whether the outputs are up to date.
+func Targets() *fs.Targets {
+	return DefaultSession.Targets()
+}
+
 // FileRead uses context ctx to read file content from path
 func FileReadWithContext(ctx context.Context, path string, args ...interface{}) *fs.FileReader {
 	return DefaultSession.FileReadWithContext(ctx, path, args...)
@@ -0,0 +1,252 @@
+package gexe
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// DefaultHistoryLimit is the number of most recent entries kept by the history of a new session
+const DefaultHistoryLimit = 1000
+
+// HistoryEntry records an operation executed by a session
+type HistoryEntry struct {
+	// Command is the command line, quoted so that it can be run by a shell
+	Command string `json:"command"`
+	// Args is the expanded argument list, including the program name
+	Args       []string  `json:"args"`
+	Dir        string    `json:"dir,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	ExitCode   int       `json:"exit_code"`
+	OutputSize int64     `json:"output_size"`
+	// Err is the error of a failed operation
+	Err string `json:"error,omitempty"`
+}
+
+// Duration returns how long the operation ran
+func (h HistoryEntry) Duration() time.Duration {
+	if h.Start.IsZero() || h.End.IsZero() {
+		return 0
+	}
+	return h.End.Sub(h.Start)
+}
+
+// Failed returns true if the operation completed with an error
+func (h HistoryEntry) Failed() bool {
+	return h.Err != ""
+}
+
+// History is the in-memory record, in completion order, of the operations executed by a session
+type History struct {
+	mu      sync.RWMutex
+	entries []HistoryEntry
+	limit   int
+}
+
+// History returns the history of the operations executed by the session
+func (e *Session) History() *History {
+	return e.history
+}
+
+// WithLimit keeps, at most, the n most recent entries (default: DefaultHistoryLimit).
+// A value <= 0 keeps all entries.
+func (h *History) WithLimit(n int) *History {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limit = n
+	h.trim()
+	return h
+}
+
+// Entries returns a copy of the recorded entries
+func (h *History) Entries() []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]HistoryEntry{}, h.entries...)
+}
+
+// Len returns the number of recorded entries
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.entries)
+}
+
+// Clear removes all entries
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = nil
+}
+
+// Last returns the most recent entry, or nil if the history is empty
+func (h *History) Last() *HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.entries) == 0 {
+		return nil
+	}
+	entry := h.entries[len(h.entries)-1]
+	return &entry
+}
+
+// LastFailure returns the most recent failed entry, or nil if no operation failed
+func (h *History) LastFailure() *HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].Failed() {
+			entry := h.entries[i]
+			return &entry
+		}
+	}
+	return nil
+}
+
+// Failures returns the failed entries
+func (h *History) Failures() (entries []HistoryEntry) {
+	for _, entry := range h.Entries() {
+		if entry.Failed() {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// Slowest returns, at most, the n entries with the longest durations, slowest first
+func (h *History) Slowest(n int) []HistoryEntry {
+	entries := h.Entries()
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Duration() > entries[j].Duration() })
+	if n >= 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// JSON returns the entries encoded as a JSON array
+func (h *History) JSON() ([]byte, error) {
+	return json.MarshalIndent(h.Entries(), "", "  ")
+}
+
+// JUnit returns the entries as a JUnit XML report, with a test suite named suite, where
+// each operation is a test case and failed operations are reported as failures.
+func (h *History) JUnit(suite string) ([]byte, error) {
+	type failure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type testCase struct {
+		Name      string   `xml:"name,attr"`
+		ClassName string   `xml:"classname,attr"`
+		Time      string   `xml:"time,attr"`
+		Failure   *failure `xml:"failure,omitempty"`
+	}
+	type testSuite struct {
+		XMLName   xml.Name   `xml:"testsuite"`
+		Name      string     `xml:"name,attr"`
+		Tests     int        `xml:"tests,attr"`
+		Failures  int        `xml:"failures,attr"`
+		Time      string     `xml:"time,attr"`
+		Timestamp string     `xml:"timestamp,attr,omitempty"`
+		Cases     []testCase `xml:"testcase"`
+	}
+
+	entries := h.Entries()
+	report := testSuite{Name: suite, Tests: len(entries)}
+	var total time.Duration
+	for _, entry := range entries {
+		tc := testCase{Name: entry.Command, ClassName: suite, Time: seconds(entry.Duration())}
+		if entry.Failed() {
+			report.Failures++
+			tc.Failure = &failure{
+				Message: entry.Err,
+				Type:    "exit",
+				Text:    fmt.Sprintf("exit code: %d\nstart: %s\nend: %s", entry.ExitCode, entry.Start.Format(time.RFC3339Nano), entry.End.Format(time.RFC3339Nano)),
+			}
+		}
+		total += entry.Duration()
+		report.Cases = append(report.Cases, tc)
+	}
+	report.Time = seconds(total)
+	if len(entries) > 0 {
+		report.Timestamp = entries[0].Start.Format("2006-01-02T15:04:05")
+	}
+
+	data, err := xml.MarshalIndent(struct {
+		XMLName xml.Name  `xml:"testsuites"`
+		Suite   testSuite `xml:"testsuite"`
+	}{Suite: report}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Script returns a POSIX shell script that runs the recorded commands in order. Operations
+// that failed to start without a command, such as parse errors, are written as comments.
+func (h *History) Script() string {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n# commands recorded in the gexe session history\n")
+	for _, entry := range h.Entries() {
+		script.WriteString("\n")
+		if entry.Failed() {
+			fmt.Fprintf(&script, "# failed (exit code %d): %s\n", entry.ExitCode, strings.ReplaceAll(entry.Err, "\n", " "))
+		}
+		if len(entry.Args) == 0 {
+			continue
+		}
+		if entry.Dir != "" {
+			fmt.Fprintf(&script, "(cd %s && %s)\n", exec.Quote(entry.Dir), entry.Command)
+			continue
+		}
+		script.WriteString(entry.Command + "\n")
+	}
+	return script.String()
+}
+
+// record adds the completed proc to the history
+func (h *History) record(proc *exec.Proc) {
+	cmd := proc.Command()
+	entry := HistoryEntry{
+		Args:       append([]string{}, cmd.Args...),
+		Dir:        cmd.Dir,
+		Start:      proc.StartTime(),
+		End:        proc.EndTime(),
+		ExitCode:   proc.ExitCode(),
+		OutputSize: proc.OutputSize(),
+	}
+	entry.Command = exec.Quote(entry.Args...)
+	if err := proc.Err(); err != nil {
+		entry.Err = err.Error()
+	}
+	if entry.End.IsZero() {
+		entry.End = time.Now()
+	}
+	if entry.Start.IsZero() {
+		entry.Start = entry.End
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	h.trim()
+}
+
+// trim drops the oldest entries above the limit, it must be called with h.mu held
+func (h *History) trim() {
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = append([]HistoryEntry{}, h.entries[len(h.entries)-h.limit:]...)
+	}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,132 @@
+//go:build !windows
+
+package gexe
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+func TestSessionHistory(t *testing.T) {
+	g := New()
+	g.SetVar("NAME", "world")
+	g.Run("echo hello $NAME")
+	g.Run(`sh -c "sleep 0.1; exit 3"`)
+	g.RunAll("echo one", "echo two")
+	g.Pipe("echo piped", "wc -c")
+
+	history := g.History()
+	if history.Len() != 6 {
+		t.Fatalf("expecting 6 entries, got %d: %v", history.Len(), history.Entries())
+	}
+
+	first := history.Entries()[0]
+	if first.Command != "echo hello world" || strings.Join(first.Args, ",") != "echo,hello,world" {
+		t.Errorf("unexpected command: %q %q", first.Command, first.Args)
+	}
+	if first.ExitCode != 0 || first.Failed() || first.OutputSize != int64(len("hello world\n")) {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	if first.Start.IsZero() || first.End.Before(first.Start) {
+		t.Errorf("unexpected times: %s %s", first.Start, first.End)
+	}
+
+	failure := history.LastFailure()
+	if failure == nil || failure.ExitCode != 3 || !strings.Contains(failure.Command, "exit 3") {
+		t.Fatalf("unexpected last failure: %+v", failure)
+	}
+	if len(history.Failures()) != 1 {
+		t.Errorf("expecting 1 failure, got %d", len(history.Failures()))
+	}
+	if slowest := history.Slowest(1); len(slowest) != 1 || slowest[0].Command != failure.Command {
+		t.Errorf("unexpected slowest: %+v", slowest)
+	}
+
+	// JSON
+	data, err := history.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 || entries[1].ExitCode != 3 {
+		t.Errorf("unexpected JSON entries: %s", data)
+	}
+
+	// JUnit
+	data, err = history.JUnit("provision")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Suite struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Cases    []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Suite.Name != "provision" || report.Suite.Tests != 6 || report.Suite.Failures != 1 {
+		t.Errorf("unexpected JUnit report:\n%s", data)
+	}
+	if report.Suite.Cases[1].Failure == nil || report.Suite.Cases[0].Failure != nil {
+		t.Errorf("unexpected JUnit failures:\n%s", data)
+	}
+
+	// shell script
+	script := history.Script()
+	if !strings.Contains(script, "echo hello world\n") || !strings.Contains(script, "# failed (exit code 3)") {
+		t.Errorf("unexpected script:\n%s", script)
+	}
+
+	history.WithLimit(2)
+	if history.Len() != 2 || history.Last().Command != "wc -c" {
+		t.Errorf("unexpected entries after limit: %v", history.Entries())
+	}
+	history.Clear()
+	if history.Len() != 0 || history.Last() != nil {
+		t.Errorf("expecting empty history")
+	}
+}
+
+func TestSessionHistoryFailedSetup(t *testing.T) {
+	g := New()
+	proc := g.NewProc("echo hello").SetUserid("no-such-user-for-gexe")
+	if proc.Run().Err() == nil {
+		t.Fatal("expecting user lookup error")
+	}
+
+	last := g.History().Last()
+	if last == nil || !last.Failed() || !strings.Contains(last.Err, "no-such-user-for-gexe") {
+		t.Fatalf("unexpected last entry: %+v", last)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.procs) != 0 {
+		t.Errorf("expecting failed proc to be untracked, got %d", len(g.procs))
+	}
+}
+
+func TestSessionHistoryDefaultLimit(t *testing.T) {
+	history := New().History()
+	for i := 0; i < DefaultHistoryLimit+5; i++ {
+		history.record(exec.NewProcArgs(context.Background(), "true"))
+	}
+	if history.Len() != DefaultHistoryLimit {
+		t.Errorf("expecting %d entries, got %d", DefaultHistoryLimit, history.Len())
+	}
+}
@@ -1,9 +1,16 @@
 package gexe
 
 import (
+	"time"
+
 	"github.com/vladimirvivien/gexe/net"
 )
 
 func (e *Session) AddressUsable(addr string) error {
 	return net.AddrUsable(e.Eval(addr))
 }
+
+// AddressReachable returns nil if a server is accepting TCP connections at addr
+func (e *Session) AddressReachable(addr string, timeout time.Duration) error {
+	return net.AddrReachable(e.Eval(addr), timeout)
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"syscall"
+	"time"
 )
 
 func AddrUsable(address string) error {
@@ -27,3 +28,13 @@ func AddrUsable(address string) error {
 	defer lsnr.Close()
 	return nil
 }
+
+// AddrReachable returns nil if a TCP connection to address can be established
+// within timeout, meaning a server is accepting connections at that address.
+func AddrReachable(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Errorf("net: %s", err)
+	}
+	return conn.Close()
+}
@@ -3,6 +3,8 @@ package gexe
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/vladimirvivien/gexe/exec"
 )
@@ -12,13 +14,13 @@ import (
 // Information about the running process is stored in *exec.Proc.
 func (e *Session) NewProcWithContext(ctx context.Context, cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.NewProcWithContextVars(ctx, cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(ctx, cmdStr, e.vars))
 }
 
 // NewProc a convenient function that calls NewProcWithContext with a default contet.
 func (e *Session) NewProc(cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.NewProcWithContextVars(context.Background(), cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(context.Background(), cmdStr, e.vars))
 }
 
 // StartProc executes the command in cmdStr, with the specified context, and returns immediately
@@ -26,7 +28,7 @@ func (e *Session) NewProc(cmdStr string, args ...interface{}) *exec.Proc {
 // Information about the running process is stored in *Proc.
 func (e *Session) StartProcWithContext(ctx context.Context, cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.StartProcWithContextVars(ctx, cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(ctx, cmdStr, e.vars)).Start()
 }
 
 // StartProc executes the command in cmdStr and returns immediately
@@ -34,33 +36,61 @@ func (e *Session) StartProcWithContext(ctx context.Context, cmdStr string, args
 // Information about the running process is stored in *Proc.
 func (e *Session) StartProc(cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.StartProcWithContextVars(context.Background(), cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(context.Background(), cmdStr, e.vars)).Start()
 }
 
 // RunProcWithContext executes command in cmdStr, with given context, and waits for the result.
 // It returns a *Proc with information about the executed process.
 func (e *Session) RunProcWithContext(ctx context.Context, cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.RunProcWithContextVars(ctx, cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(ctx, cmdStr, e.vars)).Run()
 }
 
 // RunProc executes command in cmdStr and waits for the result.
 // It returns a *Proc with information about the executed process.
 func (e *Session) RunProc(cmdStr string, args ...interface{}) *exec.Proc {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.RunProcWithContextVars(context.Background(), cmdStr, e.vars)
+	return e.trackProc(exec.NewProcWithContextVars(context.Background(), cmdStr, e.vars)).Run()
 }
 
 // Run executes cmdStr, with given context, and returns the result as a string.
 func (e *Session) RunWithContext(ctx context.Context, cmdStr string, args ...interface{}) string {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.RunWithContextVars(ctx, cmdStr, e.vars)
+	return e.RunProcWithContext(ctx, cmdStr).Result()
 }
 
 // Run executes cmdStr, waits, and returns the result as a string.
 func (e *Session) Run(cmdStr string, args ...interface{}) string {
 	cmdStr = applyFmt(cmdStr, args...)
-	return exec.RunWithContextVars(context.Background(), cmdStr, e.vars)
+	return e.RunProcWithContext(context.Background(), cmdStr).Result()
+}
+
+// NewProcArgs sets up a new process from an explicit command name and argument list without starting it.
+// The name and args are used as-is, they are not parsed nor expanded. Select arguments with
+// Proc.ExpandArgs to expand them with session variables when the process starts.
+func (e *Session) NewProcArgs(name string, args ...string) *exec.Proc {
+	return e.trackProc(exec.NewProcArgs(context.Background(), name, args...).SetVars(e.vars))
+}
+
+// RunProcArgsWithContext executes the command name, with an explicit argument list, using the
+// given context and waits for the result. The name and args are not parsed nor expanded.
+func (e *Session) RunProcArgsWithContext(ctx context.Context, name string, args ...string) *exec.Proc {
+	return e.trackProc(exec.NewProcArgs(ctx, name, args...).SetVars(e.vars)).Run()
+}
+
+// RunArgsWithContext executes the command name, with an explicit argument list, using the given
+// context and returns the result as a string. The name and args are not parsed nor expanded.
+func (e *Session) RunArgsWithContext(ctx context.Context, name string, args ...string) string {
+	return e.RunProcArgsWithContext(ctx, name, args...).Result()
+}
+
+// RunArgs executes the command name with an explicit argument list and returns the result as a string.
+// The name and args are not parsed nor expanded, making it safe to pass untrusted values as arguments.
+// To expand some of the arguments with session variables, use NewProcArgs with Proc.ExpandArgs:
+//
+//	gexe.NewProcArgs("grep", "-n", userPattern, "$HOME/notes.txt").ExpandArgs(2).Run().Result()
+func (e *Session) RunArgs(name string, args ...string) string {
+	return e.RunArgsWithContext(context.Background(), name, args...)
 }
 
 // Runout executes command cmdStr and prints out the result
@@ -70,80 +100,185 @@ func (e *Session) Runout(cmdStr string, args ...interface{}) {
 
 // Commands creates a *exe.CommandBuilder, with the specified context, to build a multi-command execution flow.
 func (e *Session) CommandsWithContext(ctx context.Context, cmdStrs ...string) *exec.CommandBuilder {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...))
 }
 
 // Commands returns a *exe.CommandBuilder to build a multi-command execution flow.
 func (e *Session) Commands(cmdStrs ...string) *exec.CommandBuilder {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...))
 }
 
 // StartAllWithContext uses the specified ctx to start sequential execution of each command, in cmdStrs, and does not
 // wait for their completion.
 func (e *Session) StartAllWithContext(ctx context.Context, cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...).Start()
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)).Start()
 }
 
 // StartAll starts the sequential execution of each command, in cmdStrs, and does not
 // wait for their completion.
 func (e *Session) StartAll(cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...).Start()
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)).Start()
 }
 
 // RunAllWithContext executes each command sequentially, in cmdStrs, and wait for their completion.
 func (e *Session) RunAllWithContext(ctx context.Context, cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...).Run()
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)).Run()
 }
 
 // RunAll executes each command sequentially, in cmdStrs, and wait for their completion.
 func (e *Session) RunAll(cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...).Run()
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)).Run()
 }
 
 // StartConcurWithContext uses specified context to start the concurrent execution of each command, in cmdStrs, and does not
 // wait for their completion.
 func (e *Session) StartConcurWithContext(ctx context.Context, cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...).Concurr()
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)).Concurr()
 }
 
 // StartConcur starts the concurrent execution of each command, in cmdStrs, and does not
 // wait for their completion.
 func (e *Session) StartConcur(cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...).Concurr()
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)).Concurr()
 }
 
 // RunConcurWithContext uses context to execute each command concurrently, in cmdStrs, and waits
 // their completion.
 func (e *Session) RunConcurWithContext(ctx context.Context, cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...).Concurr().Wait()
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)).Concurr().Wait()
 }
 
 // RunConcur executes each command concurrently, in cmdStrs, and waits
 // their completion.
 func (e *Session) RunConcur(cmdStrs ...string) *exec.CommandResult {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...).Concurr().Wait()
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)).Concurr().Wait()
 }
 
 // Pipe uses specified context to execute each command, in cmdStrs, by piping the result
 // of the previous command as input to the next command until done.
 func (e *Session) PipeWithContext(ctx context.Context, cmdStrs ...string) *exec.PipedCommandResult {
-	return exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...).Pipe()
+	return e.trackBuilder(exec.CommandsWithContextVars(ctx, e.vars, cmdStrs...)).Pipe()
 }
 
 // Pipe executes each command, in cmdStrs, by piping the result
 // of the previous command as input to the next command until done.
 func (e *Session) Pipe(cmdStrs ...string) *exec.PipedCommandResult {
-	return exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...).Pipe()
+	return e.trackBuilder(exec.CommandsWithContextVars(context.Background(), e.vars, cmdStrs...)).Pipe()
+}
+
+// ForEachWithContext returns a *exec.ForEachBuilder, with the specified context, that runs cmdStr
+// for each item in items. Each item is placed, as-is, where variable ${item} is referenced in cmdStr.
+func (e *Session) ForEachWithContext(ctx context.Context, items []string, cmdStr string) *exec.ForEachBuilder {
+	return exec.ForEachWithContextVars(ctx, e.vars, items, cmdStr).WithProcHook(e.trackProcHook)
+}
+
+// ForEach returns a *exec.ForEachBuilder that runs cmdStr for each item in items.
+// Each item is placed, as-is, where variable ${item} is referenced in cmdStr:
+//
+//	ForEach([]string{"a.txt", "b.txt"}, "gzip ${item}").WithConcurrency(4).Run()
+func (e *Session) ForEach(items []string, cmdStr string) *exec.ForEachBuilder {
+	return e.ForEachWithContext(context.Background(), items, cmdStr)
+}
+
+// ForEachReader returns a *exec.ForEachBuilder that runs cmdStr for each
+// non-empty line read from reader.
+func (e *Session) ForEachReader(reader io.Reader, cmdStr string) *exec.ForEachBuilder {
+	return exec.ForEachReaderWithContextVars(context.Background(), e.vars, reader, cmdStr).WithProcHook(e.trackProcHook)
+}
+
+// ForEachProc returns a *exec.ForEachBuilder that runs cmdStr for each non-empty
+// line written to stdout by proc. The proc is started by ForEachBuilder.Run.
+func (e *Session) ForEachProc(proc *exec.Proc, cmdStr string) *exec.ForEachBuilder {
+	return exec.ForEachProcWithContextVars(context.Background(), e.vars, proc, cmdStr).WithProcHook(e.trackProcHook)
+}
+
+// StartDetached starts cmdStr as a process fully detached from the running program (new session,
+// output appended to log files, optional pid file). The process survives the program's exit.
+// See exec.StartDetachedWithVars.
+func (e *Session) StartDetached(cmdStr string, opts exec.DetachOptions) *exec.DetachedProc {
+	return exec.StartDetachedWithVars(cmdStr, opts, e.vars)
+}
+
+// Status reports whether the process recorded in pidFile is still running
+func (e *Session) Status(pidFile string) *exec.PidStatus {
+	return exec.PidFileStatus(e.vars.Eval(pidFile))
+}
+
+// Stop terminates the process recorded in pidFile, killing it if it is
+// still running after grace, and removes the pid file.
+func (e *Session) Stop(pidFile string, grace time.Duration) error {
+	return exec.StopPidFile(e.vars.Eval(pidFile), grace)
+}
+
+// NewSupervisor returns a *exec.Supervisor, using the session variables, to keep a
+// set of services running with restart policies.
+func (e *Session) NewSupervisor() *exec.Supervisor {
+	return exec.NewSupervisorWithVars(e.vars).WithProcHook(e.trackProcHook)
 }
 
 // ParseCommand parses the string into individual command tokens
 func (e *Session) ParseCommand(cmdStr string, args ...interface{}) (cmdName string, argsList []string) {
 	cmdStr = applyFmt(cmdStr, args...)
-	result, err := exec.Parse(e.vars.Eval(cmdStr))
+	result, err := exec.Parse(e.vars.ExpandCommand(cmdStr))
 	if err != nil {
 		e.err = err
+		return
+	}
+	if len(result) == 0 {
+		return
 	}
 	cmdName = result[0]
 	argsList = result[1:]
 	return
 }
+
+// trackProc registers proc with the session, when it starts, so that it can be reached
+// by session-wide operations such as signal forwarding, and records it in the session
+// history when it is done. The proc is removed from the session once it is done.
+func (e *Session) trackProc(proc *exec.Proc) *exec.Proc {
+	return proc.OnStart(e.registerProc).OnDone(e.untrackProc).OnDone(e.history.record)
+}
+
+// trackProcHook is a proc hook that registers procs with the session
+func (e *Session) trackProcHook(proc *exec.Proc) {
+	e.trackProc(proc)
+}
+
+// trackBuilder registers the procs of a command builder with the session,
+// as they are started, and records them in its history.
+func (e *Session) trackBuilder(cb *exec.CommandBuilder) *exec.CommandBuilder {
+	return cb.WithProcHook(e.trackProcHook)
+}
+
+// registerProc adds the started proc to the session
+func (e *Session) registerProc(proc *exec.Proc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.procs = append(e.procs, proc)
+}
+
+// untrackProc removes the completed proc from the session
+func (e *Session) untrackProc(proc *exec.Proc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, p := range e.procs {
+		if p == proc {
+			e.procs = append(e.procs[:i], e.procs[i+1:]...)
+			break
+		}
+	}
+}
+
+// runningProcs returns all session procs that are currently running.
+func (e *Session) runningProcs() []*exec.Proc {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var running []*exec.Proc
+	for _, p := range e.procs {
+		if p.IsRunning() {
+			running = append(running, p)
+		}
+	}
+	return running
+}
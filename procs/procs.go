@@ -0,0 +1,183 @@
+package procs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// ErrUnsupported is returned on platforms without a /proc filesystem
+var ErrUnsupported = errors.New("procs: process inspection not supported on this platform")
+
+// ErrNoFilter is returned by Signal when no filter is provided
+var ErrNoFilter = errors.New("procs: signal requires at least one filter")
+
+// Process describes an OS process read from /proc
+type Process struct {
+	Pid        int
+	PPid       int
+	Uid        int
+	User       string
+	Name       string
+	Cmdline    []string
+	State      string
+	StartTime  time.Time
+	StartTicks uint64
+	RSS        int64
+	CPUTime    time.Duration
+	Threads    int
+}
+
+// Filter selects processes
+type Filter func(*Process) bool
+
+// ByName selects processes with the specified executable name
+func ByName(name string) Filter {
+	return func(p *Process) bool {
+		return p.Name == name
+	}
+}
+
+// ByPattern selects processes where the regular expression pattern matches the
+// name or the full command line. An invalid pattern matches nothing.
+func ByPattern(pattern string) Filter {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return func(*Process) bool { return false }
+	}
+	return func(p *Process) bool {
+		return regex.MatchString(p.Name) || regex.MatchString(p.CommandLine())
+	}
+}
+
+// ByUser selects processes owned by the specified user name or numerical id
+func ByUser(user string) Filter {
+	return func(p *Process) bool {
+		return p.User == user || fmt.Sprint(p.Uid) == user
+	}
+}
+
+// ByParent selects processes with the specified parent process id
+func ByParent(ppid int) Filter {
+	return func(p *Process) bool {
+		return p.PPid == ppid
+	}
+}
+
+// CommandLine returns the process command line as a single string
+func (p *Process) CommandLine() string {
+	var cmdline string
+	for i, arg := range p.Cmdline {
+		if i > 0 {
+			cmdline += " "
+		}
+		cmdline += arg
+	}
+	return cmdline
+}
+
+// Signal sends sig to the process
+func (p *Process) Signal(sig os.Signal) error {
+	proc, err := os.FindProcess(p.Pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(sig)
+}
+
+// Find returns running processes matching all filters, sorted by pid
+func Find(filters ...Filter) ([]*Process, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Process
+	for _, proc := range all {
+		if matchAll(proc, filters) {
+			result = append(result, proc)
+		}
+	}
+	return result, nil
+}
+
+// Signal sends sig to all processes matching all filters, excluding the current process.
+// It returns the processes that were signaled and the joined errors of failed deliveries.
+// At least one filter is required to prevent signaling every reachable process.
+func Signal(sig os.Signal, filters ...Filter) ([]*Process, error) {
+	if len(filters) == 0 {
+		return nil, ErrNoFilter
+	}
+	found, err := Find(filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var signaled []*Process
+	var errs []error
+	for _, proc := range found {
+		if proc.Pid == os.Getpid() {
+			continue
+		}
+		if err := proc.Signal(sig); err != nil {
+			errs = append(errs, fmt.Errorf("procs: signal %d: %w", proc.Pid, err))
+			continue
+		}
+		signaled = append(signaled, proc)
+	}
+	return signaled, errors.Join(errs...)
+}
+
+// Node is a process in a process tree
+type Node struct {
+	*Process
+	Children []*Node
+}
+
+// Tree returns the process tree rooted at pid
+func Tree(pid int) (*Node, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int]*Node)
+	for _, proc := range all {
+		nodes[proc.Pid] = &Node{Process: proc}
+	}
+	root, ok := nodes[pid]
+	if !ok {
+		return nil, fmt.Errorf("procs: process %d: %w", pid, os.ErrNotExist)
+	}
+	for _, proc := range all {
+		if parent, ok := nodes[proc.PPid]; ok && proc.Pid != proc.PPid {
+			parent.Children = append(parent.Children, nodes[proc.Pid])
+		}
+	}
+	return root, nil
+}
+
+// Descendants returns all processes below the node, depth first
+func (n *Node) Descendants() (procs []*Process) {
+	for _, child := range n.Children {
+		procs = append(procs, child.Process)
+		procs = append(procs, child.Descendants()...)
+	}
+	return
+}
+
+func matchAll(proc *Process, filters []Filter) bool {
+	for _, filter := range filters {
+		if !filter(proc) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortByPid(procs []*Process) {
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+}
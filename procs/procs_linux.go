@@ -0,0 +1,224 @@
+//go:build linux
+
+package procs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultClockTicks is the USER_HZ value used by Linux on all architectures
+	defaultClockTicks = 100
+	// atClkTck is the auxiliary vector entry type for sysconf(_SC_CLK_TCK)
+	atClkTck = 17
+)
+
+var (
+	// clockTicks is the kernel USER_HZ value used for /proc time fields
+	clockTicks = readClockTicks()
+
+	bootTimeOnce sync.Once
+	bootTime     time.Time
+
+	userCache sync.Map // uid -> user name
+)
+
+// List returns all running processes, sorted by pid
+func List() ([]*Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("procs: %w", err)
+	}
+
+	var result []*Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		proc, err := Get(pid)
+		if err != nil {
+			// process exited while listing
+			continue
+		}
+		result = append(result, proc)
+	}
+	sortByPid(result)
+	return result, nil
+}
+
+// Get returns information about the process with the specified pid
+func Get(pid int) (*Process, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, fmt.Errorf("procs: process %d: %w", pid, err)
+	}
+
+	proc, err := parseStat(pid, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		proc.Cmdline = parseCmdline(cmdline)
+	}
+
+	if uid, err := readUid(filepath.Join(dir, "status")); err == nil {
+		proc.Uid = uid
+		proc.User = lookupUser(uid)
+	}
+	return proc, nil
+}
+
+// parseStat parses the content of /proc/<pid>/stat (see proc(5))
+func parseStat(pid int, stat string) (*Process, error) {
+	start := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("procs: process %d: unexpected stat format", pid)
+	}
+
+	// fields after the command name start at field 3 (state)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("procs: process %d: unexpected stat format", pid)
+	}
+	field := func(n int) int64 {
+		val, _ := strconv.ParseInt(fields[n-3], 10, 64)
+		return val
+	}
+
+	startTicks := uint64(field(22))
+	return &Process{
+		Pid:        pid,
+		Name:       stat[start+1 : end],
+		State:      fields[0],
+		PPid:       int(field(4)),
+		CPUTime:    ticksToDuration(field(14) + field(15)),
+		Threads:    int(field(20)),
+		StartTicks: startTicks,
+		StartTime:  getBootTime().Add(ticksToDuration(int64(startTicks))),
+		RSS:        field(24) * int64(os.Getpagesize()),
+	}, nil
+}
+
+func parseCmdline(data []byte) []string {
+	data = []byte(strings.TrimRight(string(data), "\x00"))
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\x00")
+}
+
+// readUid reads the real user id from /proc/<pid>/status
+func readUid(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Uid:"))
+		if len(fields) == 0 {
+			break
+		}
+		return strconv.Atoi(fields[0])
+	}
+	return 0, fmt.Errorf("procs: uid not found in %s", path)
+}
+
+func lookupUser(uid int) string {
+	if name, ok := userCache.Load(uid); ok {
+		return name.(string)
+	}
+	name := strconv.Itoa(uid)
+	if usr, err := user.LookupId(name); err == nil {
+		name = usr.Username
+	}
+	userCache.Store(uid, name)
+	return name
+}
+
+// getBootTime reads the system boot time from /proc/stat
+func getBootTime() time.Time {
+	bootTimeOnce.Do(func() {
+		file, err := os.Open("/proc/stat")
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if secs, found := strings.CutPrefix(scanner.Text(), "btime "); found {
+				if val, err := strconv.ParseInt(strings.TrimSpace(secs), 10, 64); err == nil {
+					bootTime = time.Unix(val, 0)
+				}
+				return
+			}
+		}
+	})
+	return bootTime
+}
+
+func ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks) * time.Second / time.Duration(clockTicks)
+}
+
+// readClockTicks reads USER_HZ, the value of sysconf(_SC_CLK_TCK), from the
+// auxiliary vector of the running program, or returns the default value.
+func readClockTicks() int64 {
+	data, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return defaultClockTicks
+	}
+	if ticks := parseAuxv(data, atClkTck, strconv.IntSize/8); ticks > 0 {
+		return int64(ticks)
+	}
+	return defaultClockTicks
+}
+
+// parseAuxv returns the value of the entry of type key in the auxiliary vector data, made
+// of native-endian (type, value) pairs of words of wordSize bytes, or 0 if it is not found.
+func parseAuxv(data []byte, key uint64, wordSize int) uint64 {
+	word := func(b []byte) uint64 {
+		if wordSize == 4 {
+			return uint64(binary.NativeEndian.Uint32(b))
+		}
+		return binary.NativeEndian.Uint64(b)
+	}
+	for i := 0; i+2*wordSize <= len(data); i += 2 * wordSize {
+		typ, val := word(data[i:]), word(data[i+wordSize:])
+		switch typ {
+		case 0: // AT_NULL ends the vector
+			return 0
+		case key:
+			return val
+		}
+	}
+	return 0
+}
+
+// OpenFDs returns the number of open file descriptors of the process
+func OpenFDs(pid int) (int, error) {
+	entries, err := os.ReadDir(filepath.Join("/proc", strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, fmt.Errorf("procs: process %d: %w", pid, err)
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,131 @@
+//go:build linux
+
+package procs
+
+import (
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	proc, err := Get(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proc.PPid != os.Getppid() {
+		t.Errorf("expecting ppid %d, got %d", os.Getppid(), proc.PPid)
+	}
+	if proc.Uid != os.Getuid() {
+		t.Errorf("expecting uid %d, got %d", os.Getuid(), proc.Uid)
+	}
+	if proc.User == "" {
+		t.Error("expecting user name")
+	}
+	if len(proc.Cmdline) == 0 || proc.Cmdline[0] != os.Args[0] {
+		t.Errorf("unexpected command line: %v", proc.Cmdline)
+	}
+	if proc.RSS <= 0 || proc.Threads <= 0 {
+		t.Errorf("unexpected RSS %d or threads %d", proc.RSS, proc.Threads)
+	}
+	if proc.StartTime.After(time.Now()) || time.Since(proc.StartTime) > time.Hour {
+		t.Errorf("unexpected start time: %s", proc.StartTime)
+	}
+
+	if _, err := Get(-1); err == nil {
+		t.Error("expecting error for invalid pid")
+	}
+}
+
+func TestFindTreeSignal(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+
+	found, err := Find(ByParent(os.Getpid()), ByName("sleep"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Pid != cmd.Process.Pid {
+		t.Fatalf("expecting child %d, got %v", cmd.Process.Pid, found)
+	}
+	if found[0].CommandLine() != "sleep 30" {
+		t.Errorf("unexpected command line: %s", found[0].CommandLine())
+	}
+
+	tree, err := Tree(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inTree bool
+	for _, proc := range tree.Descendants() {
+		if proc.Pid == cmd.Process.Pid {
+			inTree = true
+		}
+	}
+	if !inTree {
+		t.Error("expecting child in process tree")
+	}
+
+	signaled, err := Signal(syscall.SIGTERM, ByParent(os.Getpid()), ByPattern(`^sleep 30$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signaled) != 1 {
+		t.Fatalf("expecting 1 signaled process, got %d", len(signaled))
+	}
+	select {
+	case err := <-waitErr:
+		if err == nil || !strings.Contains(err.Error(), "terminated") {
+			t.Errorf("expecting process to be terminated, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process was not signaled")
+	}
+}
+
+func TestParseAuxv(t *testing.T) {
+	auxv := func(wordSize int, words ...uint64) []byte {
+		var data []byte
+		for _, w := range words {
+			if wordSize == 4 {
+				data = binary.NativeEndian.AppendUint32(data, uint32(w))
+				continue
+			}
+			data = binary.NativeEndian.AppendUint64(data, w)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		wordSize int
+		data     []byte
+		expected uint64
+	}{
+		{name: "64-bit", wordSize: 8, data: auxv(8, 6, 4096, atClkTck, 250, 0, 0), expected: 250},
+		{name: "32-bit", wordSize: 4, data: auxv(4, atClkTck, 1000, 0, 0), expected: 1000},
+		{name: "missing", wordSize: 8, data: auxv(8, 6, 4096, 0, 0), expected: 0},
+		{name: "after end", wordSize: 8, data: auxv(8, 0, 0, atClkTck, 250), expected: 0},
+		{name: "truncated", wordSize: 8, data: auxv(8, atClkTck)[:6], expected: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if val := parseAuxv(test.data, atClkTck, test.wordSize); val != test.expected {
+				t.Errorf("expecting %d, got %d", test.expected, val)
+			}
+		})
+	}
+
+	if clockTicks <= 0 {
+		t.Errorf("unexpected clock ticks: %d", clockTicks)
+	}
+}
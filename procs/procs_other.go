@@ -0,0 +1,18 @@
+//go:build !linux
+
+package procs
+
+// List is not supported without a /proc filesystem
+func List() ([]*Process, error) {
+	return nil, ErrUnsupported
+}
+
+// Get is not supported without a /proc filesystem
+func Get(pid int) (*Process, error) {
+	return nil, ErrUnsupported
+}
+
+// OpenFDs is not supported without a /proc filesystem
+func OpenFDs(pid int) (int, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,68 @@
+package procs
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	proc := &Process{
+		Pid:     42,
+		PPid:    1,
+		Uid:     1000,
+		User:    "gopher",
+		Name:    "sleep",
+		Cmdline: []string{"/bin/sleep", "30"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		match  bool
+	}{
+		{name: "name match", filter: ByName("sleep"), match: true},
+		{name: "name mismatch", filter: ByName("sh"), match: false},
+		{name: "pattern on name", filter: ByPattern("^sl"), match: true},
+		{name: "pattern on command line", filter: ByPattern(`sleep \d+`), match: true},
+		{name: "pattern mismatch", filter: ByPattern("^bash"), match: false},
+		{name: "invalid pattern", filter: ByPattern("sleep("), match: false},
+		{name: "user name", filter: ByUser("gopher"), match: true},
+		{name: "user id", filter: ByUser("1000"), match: true},
+		{name: "user mismatch", filter: ByUser("root"), match: false},
+		{name: "parent match", filter: ByParent(1), match: true},
+		{name: "parent mismatch", filter: ByParent(2), match: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.filter(proc) != test.match {
+				t.Errorf("expecting match %t", test.match)
+			}
+		})
+	}
+}
+
+func TestNodeDescendants(t *testing.T) {
+	root := &Node{Process: &Process{Pid: 1}, Children: []*Node{
+		{Process: &Process{Pid: 2}, Children: []*Node{{Process: &Process{Pid: 3}}}},
+		{Process: &Process{Pid: 4}},
+	}}
+	var pids []int
+	for _, proc := range root.Descendants() {
+		pids = append(pids, proc.Pid)
+	}
+	if len(pids) != 3 || pids[0] != 2 || pids[1] != 3 || pids[2] != 4 {
+		t.Errorf("unexpected descendants: %v", pids)
+	}
+}
+
+func TestSignalRequiresFilter(t *testing.T) {
+	signaled, err := Signal(os.Interrupt)
+	if !errors.Is(err, ErrNoFilter) {
+		t.Fatalf("expecting ErrNoFilter, got %v", err)
+	}
+	if len(signaled) != 0 {
+		t.Errorf("expecting no signaled process, got %d", len(signaled))
+	}
+}
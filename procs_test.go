@@ -5,6 +5,8 @@ package gexe
 import (
 	"strings"
 	"testing"
+
+	"github.com/vladimirvivien/gexe/exec"
 )
 
 func TestEchoRun(t *testing.T) {
@@ -110,3 +112,101 @@ func TestEchoRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunArgs(t *testing.T) {
+	g := New().SetVar("GREETING", "hello")
+	filename := `my "file"; rm -rf $HOME`
+
+	result := g.RunArgs("echo", g.Eval("$GREETING"), filename)
+	if result != `hello my "file"; rm -rf $HOME` {
+		t.Errorf("unexpected result: %s", result)
+	}
+
+	result = g.NewProcArgs("echo", "${GREETING}:", filename).ExpandArgs(0).Run().Result()
+	if result != `hello: my "file"; rm -rf $HOME` {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestRunQuoted(t *testing.T) {
+	g := New().SetVar("GREETING", "hello")
+	args := []string{"printf", "[%s]", "$GREETING", "it's ${GREETING}", "a$", `\$x`}
+
+	result := g.Run(exec.Quote(args...))
+	if result != `[$GREETING][it's ${GREETING}][a$][\$x]` {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestRunScriptString(t *testing.T) {
+	g := New()
+	result := g.RunScriptString("GREETING=hello\necho $GREETING \\\n  world")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Val("GREETING") != "hello" {
+		t.Errorf("expecting script var in session, got %q", g.Val("GREETING"))
+	}
+	steps := result.Steps()
+	if len(steps) != 2 || steps[1].Proc.Result() != "hello world" {
+		t.Errorf("unexpected steps: %+v", steps)
+	}
+}
+
+func TestSessionAliases(t *testing.T) {
+	g := New().SetVar("NAME", "world").
+		Alias("greet", "echo hello ${NAME}").
+		Define("pair", "echo $2 $1")
+
+	if result := g.Run("greet again"); result != "hello world again" {
+		t.Errorf("unexpected alias result: %q", result)
+	}
+	if result := g.Run(`pair "a b" c`); result != "c a b" {
+		t.Errorf("unexpected macro result: %q", result)
+	}
+	if result := g.RunAll("greet", "pair 1 2").Procs()[1].Result(); result != "2 1" {
+		t.Errorf("unexpected builder result: %q", result)
+	}
+	if name, args := g.ParseCommand("greet"); name != "echo" || strings.Join(args, ",") != "hello,world" {
+		t.Errorf("unexpected parsed command: %s %v", name, args)
+	}
+}
+
+func TestSessionTrackedProcs(t *testing.T) {
+	g := New()
+	tracked := func() int {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return len(g.procs)
+	}
+
+	g.Run("echo one")
+	g.RunAll("echo two", "echo three")
+	if n := tracked(); n != 0 {
+		t.Errorf("expecting completed procs to be untracked, got %d", n)
+	}
+
+	// procs are tracked from start to completion
+	proc := g.NewProc("sleep 0.1")
+	if n := tracked(); n != 0 {
+		t.Errorf("expecting procs not started to be untracked, got %d", n)
+	}
+	proc.Start()
+	if n := tracked(); n != 1 {
+		t.Errorf("expecting started proc to be tracked, got %d", n)
+	}
+	proc.Wait()
+	if n := tracked(); n != 0 {
+		t.Errorf("expecting completed proc to be untracked, got %d", n)
+	}
+
+	// bench and supervisor procs are recorded in the history
+	g.History().Clear()
+	g.Bench(exec.BenchOptions{Runs: 2}, "echo bench")
+	sup := g.NewSupervisor()
+	sup.Add("svc", "echo service").WithRestart(exec.RestartNever)
+	sup.Start().Wait()
+	if g.History().Len() != 3 {
+		t.Errorf("expecting 3 history entries, got %d: %v", g.History().Len(), g.History().Entries())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/vladimirvivien/gexe/procs"
 )
 
 // Info returns information about the
@@ -81,3 +83,24 @@ func (p *Info) Workdir() string {
 	}
 	return path
 }
+
+// Process returns process information (i.e. RSS, CPU time, etc) about the running
+// program read from /proc. It returns nil if the information is not available.
+func (p *Info) Process() *procs.Process {
+	proc, err := procs.Get(os.Getpid())
+	if err != nil {
+		p.err = err
+		return nil
+	}
+	return proc
+}
+
+// Children returns the child processes of the running program
+func (p *Info) Children() []*procs.Process {
+	children, err := procs.Find(procs.ByParent(os.Getpid()))
+	if err != nil {
+		p.err = err
+		return nil
+	}
+	return children
+}
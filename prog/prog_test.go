@@ -2,7 +2,9 @@ package prog
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -47,3 +49,33 @@ func TestEchoProg(t *testing.T) {
 		})
 	}
 }
+
+func TestProgChildren(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process inspection requires /proc")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	info := Prog()
+	if proc := info.Process(); proc == nil || proc.Pid != os.Getpid() {
+		t.Fatalf("unexpected process info: %v (%v)", proc, info.Err())
+	}
+
+	var found bool
+	for _, child := range info.Children() {
+		if child.Pid == cmd.Process.Pid {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expecting child %d in program children (%v)", cmd.Process.Pid, info.Err())
+	}
+}
@@ -0,0 +1,31 @@
+package gexe
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// Script returns a *exec.ScriptBuilder, for the script source src, that runs with the
+// session variables. Variables declared in the script (NAME=value) are set in the session.
+func (e *Session) Script(src string) *exec.ScriptBuilder {
+	return exec.ScriptWithContextVars(context.Background(), e.vars, src).WithProcHook(e.trackProcHook)
+}
+
+// ScriptFile returns a *exec.ScriptBuilder, for the script file at path, that runs with the
+// session variables. Variables declared in the script (NAME=value) are set in the session.
+func (e *Session) ScriptFile(path string) *exec.ScriptBuilder {
+	return exec.ScriptFileWithContextVars(context.Background(), e.vars, e.vars.Eval(path)).WithProcHook(e.trackProcHook)
+}
+
+// RunScript runs the script file at path, line by line, and returns a *exec.ScriptResult with
+// the result of each step. Use Session.ScriptFile to configure options such as errexit.
+func (e *Session) RunScript(path string) *exec.ScriptResult {
+	return e.ScriptFile(path).Run()
+}
+
+// RunScriptString runs the script source src, line by line, and returns a *exec.ScriptResult
+// with the result of each step.
+func (e *Session) RunScriptString(src string) *exec.ScriptResult {
+	return e.Script(src).Run()
+}
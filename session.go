@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sync"
 
+	gexec "github.com/vladimirvivien/gexe/exec"
 	"github.com/vladimirvivien/gexe/prog"
 	"github.com/vladimirvivien/gexe/vars"
 )
@@ -26,13 +28,18 @@ type Session struct {
 	err  error
 	vars *vars.Variables // session vars
 	prog *prog.Info
+
+	mu      sync.Mutex
+	procs   []*gexec.Proc // procs created by the session, until they are done
+	history *History      // operations executed by the session
 }
 
 // New creates a new Gexe session
 func New() *Session {
 	e := &Session{
-		vars: vars.New(),
-		prog: prog.Prog(),
+		vars:    vars.New(),
+		prog:    prog.Prog(),
+		history: &History{limit: DefaultHistoryLimit},
 	}
 	return e
 }
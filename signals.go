@@ -0,0 +1,87 @@
+package gexe
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SignalForwarder relays signals received by the running program
+// to all running processes owned by a Session.
+type SignalForwarder struct {
+	session  *Session
+	sigChan  chan os.Signal
+	stopChan chan struct{}
+	stopOnce sync.Once
+	grace    atomic.Int64
+}
+
+// ForwardSignals starts relaying the specified signals, received by the running
+// program, to every running process started by the session. If no signal is
+// specified, DefaultForwardSignals are used. Processes started with Proc.SetProcGroup
+// receive the signal as a group. Use SignalForwarder.Stop to stop forwarding.
+func (e *Session) ForwardSignals(sigs ...os.Signal) *SignalForwarder {
+	if len(sigs) == 0 {
+		sigs = DefaultForwardSignals
+	}
+
+	fwd := &SignalForwarder{
+		session:  e,
+		sigChan:  make(chan os.Signal, 1),
+		stopChan: make(chan struct{}),
+	}
+	signal.Notify(fwd.sigChan, sigs...)
+	go fwd.forward()
+	return fwd
+}
+
+// WithGrace sets a grace period after a forwarded signal. Processes
+// that are still running when the grace period expires are killed.
+// A zero duration (the default) disables killing.
+func (f *SignalForwarder) WithGrace(grace time.Duration) *SignalForwarder {
+	f.grace.Store(int64(grace))
+	return f
+}
+
+// Stop stops forwarding signals and restores their default behavior
+func (f *SignalForwarder) Stop() {
+	f.stopOnce.Do(func() {
+		signal.Stop(f.sigChan)
+		close(f.stopChan)
+	})
+}
+
+func (f *SignalForwarder) forward() {
+	for {
+		select {
+		case sig := <-f.sigChan:
+			procs := f.session.runningProcs()
+			for _, proc := range procs {
+				// errors are ignored since a proc may exit
+				// before the signal is delivered
+				_ = forwardSignal(proc, sig)
+			}
+
+			grace := time.Duration(f.grace.Load())
+			if grace <= 0 || len(procs) == 0 {
+				continue
+			}
+			go func() {
+				select {
+				case <-time.After(grace):
+				case <-f.stopChan:
+					return
+				}
+				for _, proc := range procs {
+					if proc.IsRunning() {
+						_ = proc.Signal(os.Kill)
+					}
+				}
+			}()
+		case <-f.stopChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package gexe
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSessionForwardSignals(t *testing.T) {
+	tests := []struct {
+		name   string
+		cmdStr string
+		sig    syscall.Signal
+		grace  time.Duration
+	}{
+		{
+			name:   "forward SIGTERM",
+			cmdStr: "sleep 10",
+			sig:    syscall.SIGTERM,
+		},
+		{
+			name:   "forward SIGUSR1",
+			cmdStr: "sleep 10",
+			sig:    syscall.SIGUSR1,
+		},
+		{
+			name:   "kill after grace",
+			cmdStr: `/bin/sh -c "trap '' TERM; sleep 10"`,
+			sig:    syscall.SIGTERM,
+			grace:  100 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New()
+			fwd := g.ForwardSignals(test.sig).WithGrace(test.grace)
+			defer fwd.Stop()
+
+			p := g.NewProc(test.cmdStr).SetProcGroup().Start()
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := syscall.Kill(syscall.Getpid(), test.sig); err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				p.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("process did not receive forwarded signal")
+			}
+			if p.IsSuccess() {
+				t.Error("expecting process to be terminated by signal")
+			}
+		})
+	}
+}
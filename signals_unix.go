@@ -0,0 +1,25 @@
+//go:build !windows
+
+package gexe
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// DefaultForwardSignals are the signals relayed by Session.ForwardSignals
+// when none are specified.
+var DefaultForwardSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// forwardSignal sends sig to proc
+func forwardSignal(proc *exec.Proc, sig os.Signal) error {
+	return proc.Signal(sig)
+}
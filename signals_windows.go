@@ -0,0 +1,23 @@
+//go:build windows
+
+package gexe
+
+import (
+	"os"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+// DefaultForwardSignals are the signals relayed by Session.ForwardSignals
+// when none are specified. On Windows, os.Interrupt cannot be sent to
+// another process, running processes are killed instead.
+var DefaultForwardSignals = []os.Signal{os.Interrupt}
+
+// forwardSignal sends sig to proc. Since os.Interrupt cannot be sent
+// to a process on Windows, the process is killed instead.
+func forwardSignal(proc *exec.Proc, sig os.Signal) error {
+	if sig == os.Interrupt {
+		sig = os.Kill
+	}
+	return proc.Signal(sig)
+}
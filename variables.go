@@ -70,3 +70,35 @@ func (e *Session) Eval(str string, args ...interface{}) string {
 	str = applyFmt(str, args...)
 	return e.vars.Eval(str)
 }
+
+// Alias declares name as an alias for cmdStr in the session. Command strings that start
+// with name have name replaced by cmdStr before they are expanded and parsed:
+//
+//	Alias("k", "kubectl --context ${CTX}")
+//	Run("k get pods") // runs: kubectl --context <value of CTX> get pods
+func (e *Session) Alias(name, cmdStr string) *Session {
+	e.vars.Alias(name, cmdStr)
+	return e
+}
+
+// Unalias removes a session alias
+func (e *Session) Unalias(name string) *Session {
+	e.vars.Unalias(name)
+	return e
+}
+
+// Define declares a macro in the session. Command strings that start with name are replaced
+// by body, where positional parameters $1..$9, $@, and $# refer to the words following name:
+//
+//	Define("deploy", "helm upgrade $1 ./charts/$1 -n $2")
+//	Run("deploy api staging") // runs: helm upgrade api ./charts/api -n staging
+func (e *Session) Define(name, body string) *Session {
+	e.vars.Define(name, body)
+	return e
+}
+
+// Undefine removes a session macro
+func (e *Session) Undefine(name string) *Session {
+	e.vars.Undefine(name)
+	return e
+}
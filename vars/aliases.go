@@ -0,0 +1,191 @@
+package vars
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Alias declares name as an alias for cmdStr. When a command string starts with name
+// (see Variables.ExpandCommand), name is replaced with cmdStr, i.e.
+//
+//	Alias("k", "kubectl --context ${CTX}") expands "k get pods" to "kubectl --context ${CTX} get pods"
+func (v *Variables) Alias(name, cmdStr string) *Variables {
+	v.Lock()
+	defer v.Unlock()
+	if v.aliases == nil {
+		v.aliases = make(map[string]string)
+	}
+	v.aliases[name] = cmdStr
+	return v
+}
+
+// Unalias removes a previously declared alias
+func (v *Variables) Unalias(name string) *Variables {
+	v.Lock()
+	defer v.Unlock()
+	delete(v.aliases, name)
+	return v
+}
+
+// Define declares a macro, name, with a body that references positional parameters: $1 to $9
+// (or ${n}), $@ for all parameters, and $# for the number of parameters. When a command string
+// starts with name (see Variables.ExpandCommand), it is replaced with the body where the
+// parameters are the words that follow name, i.e.
+//
+//	Define("deploy", "helm upgrade $1 ./charts/$1 -n $2") expands "deploy api staging"
+//	to "helm upgrade api ./charts/api -n staging"
+func (v *Variables) Define(name, body string) *Variables {
+	v.Lock()
+	defer v.Unlock()
+	if v.macros == nil {
+		v.macros = make(map[string]string)
+	}
+	v.macros[name] = body
+	return v
+}
+
+// Undefine removes a previously declared macro
+func (v *Variables) Undefine(name string) *Variables {
+	v.Lock()
+	defer v.Unlock()
+	delete(v.macros, name)
+	return v
+}
+
+// ExpandCommand expands the alias or macro that starts the command string cmdStr, then
+// expands variables (see Variables.Eval). Aliases and macros are expanded recursively,
+// however a name is only expanded once to prevent loops.
+func (v *Variables) ExpandCommand(cmdStr string) string {
+	return v.Eval(v.expandAliases(cmdStr))
+}
+
+// expandAliases expands the alias or macro that starts cmdStr, without expanding variables
+func (v *Variables) expandAliases(cmdStr string) string {
+	expanded := make(map[string]bool)
+	for {
+		words := splitWords(cmdStr)
+		if len(words) == 0 || expanded[words[0]] {
+			return cmdStr
+		}
+		name := words[0]
+
+		if alias, ok := v.alias(name); ok {
+			rest := strings.TrimLeftFunc(cmdStr, unicode.IsSpace)[len(name):]
+			cmdStr = alias + rest
+		} else if body, ok := v.macro(name); ok {
+			cmdStr = expandParams(body, words[1:])
+		} else {
+			return cmdStr
+		}
+		expanded[name] = true
+	}
+}
+
+// alias looks up an alias in the variables, then in parent scopes
+func (v *Variables) alias(name string) (string, bool) {
+	v.RLock()
+	alias, ok := v.aliases[name]
+	v.RUnlock()
+	if !ok && v.parent != nil {
+		return v.parent.alias(name)
+	}
+	return alias, ok
+}
+
+// macro looks up a macro in the variables, then in parent scopes
+func (v *Variables) macro(name string) (string, bool) {
+	v.RLock()
+	body, ok := v.macros[name]
+	v.RUnlock()
+	if !ok && v.parent != nil {
+		return v.parent.macro(name)
+	}
+	return body, ok
+}
+
+// expandParams replaces the positional parameters of a macro body with params.
+// Escaped references (i.e. \$1) are left for variable expansion.
+func expandParams(body string, params []string) string {
+	var result strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == '\\' && i+1 < len(body) && body[i+1] == '$' {
+			result.WriteString(body[i : i+2])
+			i++
+			continue
+		}
+		if c != '$' || i+1 == len(body) {
+			result.WriteByte(c)
+			continue
+		}
+
+		next := body[i+1]
+		switch {
+		case next == '@':
+			result.WriteString(strings.Join(params, " "))
+			i++
+		case next == '#':
+			result.WriteString(strconv.Itoa(len(params)))
+			i++
+		case next >= '1' && next <= '9':
+			result.WriteString(param(params, int(next-'0')))
+			i++
+		case next == '{':
+			end := strings.IndexByte(body[i:], '}')
+			n, err := strconv.Atoi(body[i+2 : i+max(end, 2)])
+			if end < 0 || err != nil || n < 1 {
+				result.WriteByte(c)
+				continue
+			}
+			result.WriteString(param(params, n))
+			i += end
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return result.String()
+}
+
+func param(params []string, n int) string {
+	if n > len(params) {
+		return ""
+	}
+	return params[n-1]
+}
+
+// splitWords splits cmdStr into words separated by unquoted spaces. Quotes and
+// escapes are kept in the words so that they are preserved when words are substituted.
+func splitWords(cmdStr string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+	for _, r := range cmdStr {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		}
+		word.WriteRune(r)
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
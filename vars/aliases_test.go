@@ -0,0 +1,68 @@
+package vars
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandCommand(t *testing.T) {
+	v := New().SetVar("CTX", "prod").
+		Alias("k", "kubectl --context ${CTX}").
+		Alias("kp", "k get pods").
+		Alias("loop", "loop again").
+		Define("deploy", "helm upgrade $1 ./charts/$1 -n $2").
+		Define("all", "echo $# $@").
+		Define("braced", "echo ${2} ${CTX} \\$1").
+		Define("kd", "k describe $1")
+
+	tests := []struct {
+		name     string
+		cmd      string
+		expected string
+	}{
+		{name: "no alias", cmd: "echo hello", expected: "echo hello"},
+		{name: "alias", cmd: "k get pods", expected: "kubectl --context prod get pods"},
+		{name: "alias with spaces", cmd: "  k  get pods", expected: "kubectl --context prod  get pods"},
+		{name: "alias prefix only", cmd: "kubectl get pods", expected: "kubectl get pods"},
+		{name: "nested alias", cmd: "kp -A", expected: "kubectl --context prod get pods -A"},
+		{name: "alias loop", cmd: "loop", expected: "loop again"},
+		{name: "macro", cmd: "deploy api staging", expected: "helm upgrade api ./charts/api -n staging"},
+		{name: "macro missing param", cmd: "deploy api", expected: "helm upgrade api ./charts/api -n "},
+		{name: "macro all params", cmd: `all a "b c" d`, expected: `echo 3 a "b c" d`},
+		{name: "macro braced and escaped", cmd: "braced a b", expected: "echo b prod $1"},
+		{name: "macro with alias", cmd: "kd pod/web", expected: "kubectl --context prod describe pod/web"},
+		{name: "macro param var", cmd: "deploy $CTX us", expected: "helm upgrade prod ./charts/prod -n us"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := v.ExpandCommand(test.cmd); actual != test.expected {
+				t.Errorf("expecting %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	child := v.Child().Alias("k", "kubectl")
+	if actual := child.ExpandCommand("kd x"); actual != "kubectl describe x" {
+		t.Errorf("unexpected child expansion: %q", actual)
+	}
+	if actual := v.Unalias("k").ExpandCommand("k get"); actual != "k get" {
+		t.Errorf("unexpected expansion after unalias: %q", actual)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		expected []string
+	}{
+		{cmd: "", expected: nil},
+		{cmd: "a  b\tc", expected: []string{"a", "b", "c"}},
+		{cmd: `a "b c" 'd e' f\ g`, expected: []string{"a", `"b c"`, `'d e'`, `f\ g`}},
+		{cmd: `a "b \" c"`, expected: []string{"a", `"b \" c"`}},
+	}
+	for _, test := range tests {
+		if actual := splitWords(test.cmd); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: expecting %q, got %q", test.cmd, test.expected, actual)
+		}
+	}
+}
@@ -24,7 +24,10 @@ type Variables struct {
 	sync.RWMutex
 	err        error
 	vars       map[string]string
+	aliases    map[string]string
+	macros     map[string]string
 	escapeChar rune
+	parent     *Variables
 }
 
 // New construction function to create a new Variables
@@ -32,6 +35,15 @@ func New() *Variables {
 	return &Variables{vars: make(map[string]string), escapeChar: '\\'}
 }
 
+// Child creates a new Variables scope that inherits the variables of v.
+// Variables set in the child scope are not visible to v.
+func (v *Variables) Child() *Variables {
+	child := New()
+	child.escapeChar = v.escapeChar
+	child.parent = v
+	return child
+}
+
 // WithEscapeChar sets the espacape char for the variable
 func (v *Variables) WithEscapeChar(r rune) *Variables {
 	v.escapeChar = r
@@ -108,14 +120,19 @@ func (v *Variables) UnsetVar(name string) *Variables {
 	return v
 }
 
-// Val searches for a gexe session variable with provided key, if not found
-// searches for an environment variable with that key.
+// Val searches for a gexe session variable with provided key, then in parent
+// scopes (see Variables.Child), and if not found searches for an environment
+// variable with that key.
 func (v *Variables) Val(key string) string {
 	v.RLock()
-	defer v.RUnlock()
-	if val, ok := v.vars[key]; ok {
+	val, ok := v.vars[key]
+	v.RUnlock()
+	if ok {
 		return val
 	}
+	if v.parent != nil {
+		return v.parent.Val(key)
+	}
 	return os.Getenv(key)
 }
 
@@ -407,3 +407,27 @@ func TestVariables_Eval(t *testing.T) {
 		})
 	}
 }
+
+func TestVariables_Child(t *testing.T) {
+	parent := New().SetVar("foo", "bar").SetVar("fizz", "buzz")
+	child := parent.Child().SetVar("foo", "baz").SetVar("item", "${fizz}")
+
+	if val := child.Val("foo"); val != "baz" {
+		t.Errorf("expecting child value baz, got %s", val)
+	}
+	if val := child.Val("fizz"); val != "buzz" {
+		t.Errorf("expecting inherited value buzz, got %s", val)
+	}
+	if val := child.Val("item"); val != "buzz" {
+		t.Errorf("expecting expanded value buzz, got %s", val)
+	}
+	if val := parent.Val("foo"); val != "bar" {
+		t.Errorf("child scope leaked into parent: %s", val)
+	}
+	if val := parent.Val("item"); val != "" {
+		t.Errorf("child var visible in parent: %s", val)
+	}
+	if val := child.Eval("${foo}-${fizz}"); val != "baz-buzz" {
+		t.Errorf("unexpected eval: %s", val)
+	}
+}
@@ -0,0 +1,15 @@
+package gexe
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/gexe/wait"
+)
+
+// WaitFor waits, concurrently, until all conditions are satisfied or time out.
+// Conditions are created with the wait package (i.e. wait.TCP, wait.Path, wait.HTTP, wait.Output):
+//
+//	err := g.WaitFor(ctx, wait.TCP("localhost:5432"), wait.HTTP("http://localhost:8080/health", 200))
+func (e *Session) WaitFor(ctx context.Context, conditions ...*wait.Condition) error {
+	return wait.For(ctx, conditions...)
+}
@@ -0,0 +1,172 @@
+package wait
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/vladimirvivien/gexe/exec"
+	"github.com/vladimirvivien/gexe/fs"
+	"github.com/vladimirvivien/gexe/http"
+	"github.com/vladimirvivien/gexe/net"
+)
+
+const (
+	// DefaultInterval is the default polling interval of a condition
+	DefaultInterval = 250 * time.Millisecond
+	// DefaultTimeout is the default time to wait for a condition
+	DefaultTimeout = time.Minute
+)
+
+// errNotReady is returned by a condition that is not yet satisfied
+var errNotReady = errors.New("not ready")
+
+// Condition is a readiness check that is polled until it is satisfied or times out
+type Condition struct {
+	desc     string
+	check    func(ctx context.Context) error
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// Func creates a condition, described by desc, that is satisfied when check returns nil
+func Func(desc string, check func(ctx context.Context) error) *Condition {
+	return &Condition{desc: desc, check: check, interval: DefaultInterval, timeout: DefaultTimeout}
+}
+
+// TCP creates a condition that is satisfied when address accepts TCP connections
+func TCP(address string) *Condition {
+	return Func(fmt.Sprintf("tcp %s", address), func(ctx context.Context) error {
+		return net.AddrReachable(address, time.Second)
+	})
+}
+
+// Path creates a condition that is satisfied when path exists
+func Path(path string) *Condition {
+	return Func(fmt.Sprintf("path %s", path), func(ctx context.Context) error {
+		if !fs.Path(path).Exists() {
+			return fmt.Errorf("path does not exist")
+		}
+		return nil
+	})
+}
+
+// HTTP creates a condition that is satisfied when a GET request to url returns the expected status code
+func HTTP(url string, status int) *Condition {
+	return Func(fmt.Sprintf("http %s", url), func(ctx context.Context) error {
+		res := http.GetWithContextVars(ctx, url, nil).WithTimeout(5 * time.Second).Do()
+		if err := res.Err(); err != nil {
+			return err
+		}
+		defer res.Body().Close()
+		if res.StatusCode() != status {
+			return fmt.Errorf("expecting status %d, got %d", status, res.StatusCode())
+		}
+		return nil
+	})
+}
+
+// Output creates a condition that is satisfied when pattern matches the combined output
+// of proc. The condition fails immediately if the process completes without a match.
+// Output captured so far is matched on every check, streaming output spilled to disk (see exec.CaptureSpill).
+func Output(proc *exec.Proc, pattern string) *Condition {
+	desc := fmt.Sprintf("output /%s/", pattern)
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return Func(desc, func(ctx context.Context) error {
+			return &permanentError{err: err}
+		})
+	}
+	return Func(desc, func(ctx context.Context) error {
+		if regex.MatchReader(bufio.NewReader(proc.OutputReader())) {
+			return nil
+		}
+		select {
+		case <-proc.Done():
+			if err := proc.Err(); err != nil {
+				return &permanentError{err: err}
+			}
+			return &permanentError{err: errors.New("process completed without match")}
+		default:
+		}
+		return errNotReady
+	})
+}
+
+// WithInterval sets the polling interval of the condition
+func (c *Condition) WithInterval(interval time.Duration) *Condition {
+	c.interval = interval
+	return c
+}
+
+// WithTimeout sets the maximum time to wait for the condition
+func (c *Condition) WithTimeout(timeout time.Duration) *Condition {
+	c.timeout = timeout
+	return c
+}
+
+// String returns the condition description
+func (c *Condition) String() string {
+	return c.desc
+}
+
+// Wait polls the condition until it is satisfied, its timeout expires, or ctx is done.
+// The returned error describes the condition and the last check failure.
+func (c *Condition) Wait(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		err := c.check(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permErr *permanentError
+		if errors.As(err, &permErr) {
+			return fmt.Errorf("wait: %s: %w", c.desc, permErr.err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("wait: %s: timed out after %s: %w", c.desc, time.Since(start).Round(time.Millisecond), err)
+			}
+			return fmt.Errorf("wait: %s: %w: %s", c.desc, ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// For waits, concurrently, for all conditions to be satisfied. It returns
+// the joined errors of the conditions that were not satisfied.
+func For(ctx context.Context, conditions ...*Condition) error {
+	errs := make([]error, len(conditions))
+	done := make(chan struct{})
+	for i, cond := range conditions {
+		go func(i int, cond *Condition) {
+			errs[i] = cond.Wait(ctx)
+			done <- struct{}{}
+		}(i, cond)
+	}
+	for range conditions {
+		<-done
+	}
+	return errors.Join(errs...)
+}
+
+// permanentError signals that a condition can never be satisfied
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
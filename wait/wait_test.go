@@ -0,0 +1,134 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		cond     func(t *testing.T) *Condition
+		errMatch string
+	}{
+		{
+			name: "tcp ready",
+			cond: func(t *testing.T) *Condition {
+				lsnr, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { lsnr.Close() })
+				return TCP(lsnr.Addr().String())
+			},
+		},
+		{
+			name: "tcp not ready",
+			cond: func(t *testing.T) *Condition {
+				lsnr, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				addr := lsnr.Addr().String()
+				lsnr.Close()
+				return TCP(addr).WithTimeout(200 * time.Millisecond).WithInterval(50 * time.Millisecond)
+			},
+			errMatch: "timed out",
+		},
+		{
+			name: "path created later",
+			cond: func(t *testing.T) *Condition {
+				path := filepath.Join(t.TempDir(), "ready")
+				time.AfterFunc(100*time.Millisecond, func() { os.WriteFile(path, []byte("ok"), 0644) })
+				return Path(path).WithInterval(20 * time.Millisecond)
+			},
+		},
+		{
+			name: "path missing",
+			cond: func(t *testing.T) *Condition {
+				return Path(filepath.Join(t.TempDir(), "missing")).WithTimeout(100 * time.Millisecond)
+			},
+			errMatch: "path does not exist",
+		},
+		{
+			name: "http status",
+			cond: func(t *testing.T) *Condition {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNoContent)
+				}))
+				t.Cleanup(server.Close)
+				return HTTP(server.URL, http.StatusNoContent)
+			},
+		},
+		{
+			name: "http unexpected status",
+			cond: func(t *testing.T) *Condition {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}))
+				t.Cleanup(server.Close)
+				return HTTP(server.URL, http.StatusOK).WithTimeout(100 * time.Millisecond)
+			},
+			errMatch: "expecting status 200, got 503",
+		},
+		{
+			name: "custom func",
+			cond: func(t *testing.T) *Condition {
+				calls := 0
+				return Func("counter", func(ctx context.Context) error {
+					calls++
+					if calls < 3 {
+						return errors.New("too few calls")
+					}
+					return nil
+				}).WithInterval(10 * time.Millisecond)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cond(t).Wait(context.Background())
+			if test.errMatch == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.errMatch) {
+				t.Fatalf("expecting error %q, got %v", test.errMatch, err)
+			}
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	dir := t.TempDir()
+	err := For(context.Background(),
+		Path(dir),
+		Path(filepath.Join(dir, "missing-a")).WithTimeout(50*time.Millisecond),
+		Path(filepath.Join(dir, "missing-b")).WithTimeout(50*time.Millisecond),
+	)
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+	for _, name := range []string{"missing-a", "missing-b"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expecting error to describe %s: %s", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := For(ctx, Path(filepath.Join(dir, "missing"))); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expecting canceled error, got %v", err)
+	}
+}
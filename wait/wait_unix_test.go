@@ -0,0 +1,61 @@
+//go:build !windows
+
+package wait
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/gexe/exec"
+)
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdStr   string
+		pattern  string
+		errMatch string
+	}{
+		{
+			name:    "log line appears",
+			cmdStr:  `/bin/sh -c "echo starting; sleep 0.2; echo listening on port 8080; sleep 10"`,
+			pattern: `listening on port \d+`,
+		},
+		{
+			name:     "process exits without match",
+			cmdStr:   `/bin/sh -c "echo starting"`,
+			pattern:  `listening`,
+			errMatch: "completed without match",
+		},
+		{
+			name:     "invalid pattern",
+			cmdStr:   `/bin/sh -c "echo starting"`,
+			pattern:  `listening(`,
+			errMatch: "error parsing regexp",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proc := exec.StartProc(test.cmdStr)
+			if err := proc.Err(); err != nil {
+				t.Fatal(err)
+			}
+			go proc.Wait()
+			defer proc.Kill()
+
+			err := Output(proc, test.pattern).WithInterval(20 * time.Millisecond).WithTimeout(5 * time.Second).Wait(context.Background())
+			if test.errMatch == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.errMatch) {
+				t.Fatalf("expecting error %q, got %v", test.errMatch, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package gexe
+
+import (
+	"context"
+
+	"github.com/vladimirvivien/gexe/exec"
+	"github.com/vladimirvivien/gexe/fs"
+)
+
+// Watch returns a *exec.WatchBuilder that reruns a command, or function, when files matching
+// the paths or glob patterns (expanded with session variables) change:
+//
+//	gexe.Watch("./**/*.go").Ignore(".git").Run("go test ./...")
+func (e *Session) Watch(patterns ...string) *exec.WatchBuilder {
+	return e.WatchWithContext(context.Background(), patterns...)
+}
+
+// WatchWithContext returns a *exec.WatchBuilder, using the specified context, that reruns a command
+// or function when files matching the paths or glob patterns change. Watching stops when ctx is done.
+func (e *Session) WatchWithContext(ctx context.Context, patterns ...string) *exec.WatchBuilder {
+	return exec.WatchWithContextVars(ctx, e.vars, patterns...).WithProcHook(e.trackProcHook)
+}
+
+// Watcher returns a *fs.Watcher that reports changes to files matching the paths or glob patterns
+// as a channel of events (see fs.Watcher.Events). It must be started with Watcher.Start.
+func (e *Session) Watcher(patterns ...string) *fs.Watcher {
+	return fs.WatcherWithVars(e.vars, patterns...)
+}